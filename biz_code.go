@@ -0,0 +1,156 @@
+package errors
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// BizCode is a biz code structured as a service ID, a module ID and a
+// sequence number in the decimal digits SSSSMMMQQQ: the service ID is the
+// four leading digits, the module ID the next three and the sequence the
+// last three. For example 1200001301 is the sequence 301 of the module 1 of
+// the service 1200.
+//
+// The short codes of eight digits SSSSQQQQ, such as the preset codes of
+// ErrCode, have a four digit sequence in the module 0: 12000005 is the
+// sequence 5 of the service 1200.
+type BizCode uint32
+
+// The bounds of the parts of a BizCode.
+const (
+	MinServiceID     uint32 = 1000
+	MaxServiceID     uint32 = 9999 // 4294 for the codes of ten digits
+	MaxModuleID      uint32 = 999
+	MaxSequence      uint32 = 999
+	MaxShortSequence uint32 = 9999
+)
+
+const (
+	bizCodeModuleBase       = 1000
+	bizCodeServiceBase      = 1000 * bizCodeModuleBase
+	shortBizCodeServiceBase = 10000
+
+	minShortBizCode = 10000000
+	maxShortBizCode = 99999999
+	minBizCode      = 1000000000
+)
+
+// ComposeBizCode returns the BizCode of ten digits of the sequence of the
+// module of the service. An error is returned if a part is out of bounds or
+// the code overflows uint32.
+func ComposeBizCode(service, module, sequence uint32) (BizCode, error) {
+	if service < MinServiceID || service > MaxServiceID || module > MaxModuleID || sequence > MaxSequence {
+		return 0, fmt.Errorf("biz code %d-%03d-%03d out of bounds", service, module, sequence)
+	}
+	code := uint64(service)*bizCodeServiceBase + uint64(module)*bizCodeModuleBase + uint64(sequence)
+	if code > uint64(^uint32(0)) {
+		return 0, fmt.Errorf("biz code %d-%03d-%03d overflows", service, module, sequence)
+	}
+	return BizCode(code), nil
+}
+
+// MustComposeBizCode is like ComposeBizCode but panics on error.
+func MustComposeBizCode(service, module, sequence uint32) BizCode {
+	code, err := ComposeBizCode(service, module, sequence)
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// ComposeShortBizCode returns the short BizCode of eight digits of the
+// sequence of the service.
+func ComposeShortBizCode(service, sequence uint32) (BizCode, error) {
+	if service < MinServiceID || service > MaxServiceID || sequence > MaxShortSequence {
+		return 0, fmt.Errorf("biz code %d-%04d out of bounds", service, sequence)
+	}
+	return BizCode(service*shortBizCodeServiceBase + sequence), nil
+}
+
+// ParseBizCode parses a biz code in the format of BizCode.String, e.g.
+// "1200-001-301" or "1200-0005", or as a plain number, e.g. "1200001301".
+func ParseBizCode(s string) (BizCode, error) {
+	parts := strings.Split(s, "-")
+	var ids [3]uint32
+	for i, part := range parts {
+		if i == len(ids) {
+			break
+		}
+		id, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid biz code %q", s)
+		}
+		ids[i] = uint32(id)
+	}
+	switch len(parts) {
+	case 1:
+		code := BizCode(ids[0])
+		if err := code.Validate(); err != nil || code == 0 {
+			return 0, fmt.Errorf("invalid biz code %q", s)
+		}
+		return code, nil
+	case 2:
+		return ComposeShortBizCode(ids[0], ids[1])
+	case 3:
+		return ComposeBizCode(ids[0], ids[1], ids[2])
+	}
+	return 0, fmt.Errorf("invalid biz code %q", s)
+}
+
+// Validate returns an error if c has neither ten nor eight digits. The code
+// 0, OkBizCode, is valid.
+func (c BizCode) Validate() error {
+	if c == 0 || c.isShort() || c >= minBizCode {
+		return nil
+	}
+	return fmt.Errorf("biz code %d has neither ten nor eight digits", uint32(c))
+}
+
+func (c BizCode) isShort() bool {
+	return c >= minShortBizCode && c <= maxShortBizCode
+}
+
+// Service returns the service ID of c, or 0 if c is invalid.
+func (c BizCode) Service() uint32 {
+	switch {
+	case c.isShort():
+		return uint32(c) / shortBizCodeServiceBase
+	case c >= minBizCode:
+		return uint32(c) / bizCodeServiceBase
+	}
+	return 0
+}
+
+// Module returns the module ID of c, 0 for the short codes.
+func (c BizCode) Module() uint32 {
+	if c < minBizCode {
+		return 0
+	}
+	return uint32(c) / bizCodeModuleBase % 1000
+}
+
+// Sequence returns the sequence number of c.
+func (c BizCode) Sequence() uint32 {
+	if c.isShort() {
+		return uint32(c) % shortBizCodeServiceBase
+	}
+	return uint32(c) % bizCodeModuleBase
+}
+
+// Int returns c as the uint32 of CodeError.BizCode.
+func (c BizCode) Int() uint32 {
+	return uint32(c)
+}
+
+// String returns the parts of c, e.g. "1200-001-301" or "1200-0005" for a
+// short code, or the plain number if c is invalid.
+func (c BizCode) String() string {
+	switch {
+	case c.isShort():
+		return fmt.Sprintf("%d-%04d", c.Service(), c.Sequence())
+	case c >= minBizCode:
+		return fmt.Sprintf("%d-%03d-%03d", c.Service(), c.Module(), c.Sequence())
+	}
+	return strconv.FormatUint(uint64(c), 10)
+}
@@ -0,0 +1,179 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// maxErrorBodySize bounds the body read from an error response, the rest of
+// the body is ignored.
+const maxErrorBodySize = 64 << 10
+
+// envelope is the body written by Response and HzResponse.
+type envelope struct {
+	Code      uint32          `json:"code"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data"`
+	RequestID string          `json:"request_id"`
+	TraceID   string          `json:"trace_id"`
+}
+
+// DecodeResponse reads and closes the body of resp, written by Response or
+// HzResponse. On success the data of the body is unmarshalled into out, if
+// not nil, and an empty body, e.g. of 204 No Content, leaves out unchanged.
+// Otherwise the error of the body is returned as a *CodeError: its http
+// status is the one of resp, its biz code is the code of the body and its
+// grpc code is the one declared for the biz code in the DefaultRegistry, or
+// else the one of the http status, see FromHTTPStatus. The message of the
+// body is kept as public message, so that the error can be responded as is.
+// The error responses which are not a JSON envelope, e.g. the error page of a
+// proxy, get the mapping of their http status and its status text.
+//
+//	resp, err := http.Get(url)
+//	if err != nil {
+//	    return err
+//	}
+//	var order Order
+//	if err := errors.DecodeResponse(resp, &order); errors.IsNotFound(err) {
+//	    ...
+//	}
+func DecodeResponse(resp *http.Response, out interface{}) error {
+	return decodeResponse(1, resp, out)
+}
+
+// decodeResponse is DecodeResponse with the error located callDepth frames
+// above the caller.
+func decodeResponse(callDepth int, resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		var env envelope
+		if isJSONResponse(resp) {
+			body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+			if err != nil {
+				return Annotate(err, "read response")
+			}
+			// A body which is not an envelope is left to the http status.
+			_ = json.Unmarshal(body, &env)
+		}
+		return responseError(callDepth+1, resp, env)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Annotate(err, "read response")
+	}
+	if len(bytes.TrimSpace(body)) == 0 {
+		// E.g. 204 No Content.
+		return nil
+	}
+	var env envelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		return Annotate(err, "decode response")
+	}
+	if env.Code != OkBizCode {
+		// The biz code errors of NewBizCodeError.
+		codeErr := newCodeError(callDepth+1, "%s", "", []interface{}{env.Message}, responseOptions(resp, env, WithBizCode(env.Code))...)
+		return newBizCodeError(codeErr)
+	}
+	if out == nil || len(env.Data) == 0 || string(env.Data) == "null" {
+		return nil
+	}
+	if err := json.Unmarshal(env.Data, out); err != nil {
+		return Annotate(err, "decode response data")
+	}
+	return nil
+}
+
+// responseError returns the CodeError of the error response resp with the
+// envelope env, located callDepth frames above the caller.
+func responseError(callDepth int, resp *http.Response, env envelope) *CodeError {
+	m := FromHTTPStatus(uint32(resp.StatusCode))
+	if env.Code == OkBizCode {
+		env.Code = m.BizCode
+	}
+	if env.Message == "" {
+		env.Message = http.StatusText(resp.StatusCode)
+	}
+	code := m.GRPCCode
+	if spec, ok := LookupCode(env.Code); ok && env.Code != OkBizCode {
+		code = spec.GRPCCode
+	}
+	if code == codes.OK {
+		code = codes.Unknown
+	}
+	opts := responseOptions(resp, env, WithGRPCCode(code), WithHTTPStatus(uint32(resp.StatusCode)), WithBizCode(env.Code))
+	return newCodeError(callDepth+1, "%s", "", []interface{}{env.Message}, opts...)
+}
+
+// responseOptions returns opts completed with the public message, the request
+// and trace IDs and the Retry-After header of the response.
+func responseOptions(resp *http.Response, env envelope, opts ...Option) []Option {
+	opts = append(opts, WithPublicMessage(env.Message))
+	if env.RequestID != "" {
+		opts = append(opts, WithDetails(FieldRequestID, env.RequestID))
+	}
+	if env.TraceID != "" {
+		opts = append(opts, WithDetails(FieldTraceID, env.TraceID))
+	}
+	if delay, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+		opts = append(opts, WithRetryAfter(delay))
+	}
+	return opts
+}
+
+// parseRetryAfter parses a Retry-After header, in seconds or as an http date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseUint(value, 10, 32); err == nil {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return max(time.Until(t), 0), true
+	}
+	return 0, false
+}
+
+func isJSONResponse(resp *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return mediaType == "application/json"
+}
+
+// Client sends requests to the services responding with Response and
+// HzResponse, and decodes their responses with DecodeResponse. It wraps an
+// http.Client rather than being an http.RoundTripper: the RoundTripper
+// contract forbids returning an error for a response which was received, so
+// that the error responses can't be returned as a CodeError there. For
+// example:
+//
+//	client := &errors.Client{}
+//	var order Order
+//	err := client.Do(req, &order)
+//	if errors.IsUnavailable(err) {
+//	    ...
+//	}
+type Client struct {
+	HTTPClient *http.Client // http.DefaultClient if nil
+}
+
+// Do sends req and decodes the response into out, see DecodeResponse. The
+// transport errors are returned as is, see FromError to classify them.
+func (c *Client) Do(req *http.Request, out interface{}) error {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	return decodeResponse(1, resp, out)
+}
@@ -0,0 +1,109 @@
+package errors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+)
+
+func TestDecodeResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const bizCode = 1200009903 // not registered
+	engine := gin.New()
+	engine.GET("/ok", func(g *gin.Context) { ResponseOk(g, map[string]int{"id": 42}) })
+	engine.GET("/not-found", func(g *gin.Context) { ResponseErr(g, NotFoundf("order %d", 42)) })
+	engine.GET("/exhausted", func(g *gin.Context) {
+		ResponseErr(g, New(WithGRPCCode(codes.ResourceExhausted), WithRetryAfter(2*time.Second)))
+	})
+	engine.GET("/biz", func(g *gin.Context) { ResponseErr(g, NewBizCodeErrorf(bizCode, "insufficient balance")) })
+	engine.GET("/proxy", func(g *gin.Context) { g.String(http.StatusBadGateway, "<html>bad gateway</html>") })
+	engine.GET("/no-content", func(g *gin.Context) { g.Status(http.StatusNoContent) })
+	engine.GET("/not-envelope", func(g *gin.Context) { g.JSON(http.StatusBadRequest, gin.H{"error": "x"}) })
+	server := httptest.NewServer(engine)
+	defer server.Close()
+
+	get := func(path string) (*http.Response, error) {
+		return http.Get(server.URL + path)
+	}
+	resp, err := get("/ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct{ ID int }
+	if err := DecodeResponse(resp, &out); err != nil || out.ID != 42 {
+		t.Fatalf("DecodeResponse = %v, %+v", err, out)
+	}
+
+	tests := []struct {
+		path     string
+		code     codes.Code
+		httpCode uint32
+		bizCode  uint32
+		message  string
+	}{
+		{"/not-found", codes.NotFound, 404, ErrCodeNotFound.Int(), "record not found"},
+		{"/exhausted", codes.ResourceExhausted, 429, ErrCodeResourceExhausted.Int(), "too many requests"},
+		{"/biz", codes.OK, 200, bizCode, "insufficient balance"},
+		{"/proxy", codes.Unknown, 502, ErrCodeUnknown.Int(), "Bad Gateway"},
+		{"/not-envelope", codes.InvalidArgument, 400, ErrCodeBadRequest.Int(), "Bad Request"},
+	}
+	client := &Client{}
+	do := func(path string, out interface{}) error {
+		req, err := http.NewRequest(http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client.Do(req, out)
+	}
+	for _, tt := range tests {
+		resp, err := get(tt.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := DecodeResponse(resp, nil)
+		for _, err := range []error{decoded, do(tt.path, nil)} {
+			codeErr, ok := AsCodeError(err)
+			if !ok || codeErr.Code != tt.code || codeErr.HTTPCode != tt.httpCode || codeErr.BizCode != tt.bizCode {
+				t.Errorf("%s: unexpected error %#v", tt.path, err)
+				continue
+			}
+			if PublicMessage(err) != tt.message {
+				t.Errorf("%s: public message = %q", tt.path, PublicMessage(err))
+			}
+		}
+	}
+	if delay, ok := RetryAfter(DecodeResponse(mustGet(t, server.URL+"/exhausted"), nil)); !ok || delay != 2*time.Second {
+		t.Errorf("RetryAfter = %v, %v", delay, ok)
+	}
+	for _, err := range []error{DecodeResponse(mustGet(t, server.URL+"/not-found"), nil), do("/not-found", nil)} {
+		codeErr, ok := AsCodeError(err)
+		if !ok {
+			t.Fatalf("%#v is not a CodeError", err)
+		}
+		if file, _ := codeErr.Location(); !strings.HasSuffix(file, "client_test.go") {
+			t.Errorf("location %s is not the caller", file)
+		}
+	}
+
+	out.ID = 0
+	if err := do("/ok", &out); err != nil || out.ID != 42 {
+		t.Errorf("Client.Do = %v, %+v", err, out)
+	}
+	if err := do("/no-content", &out); err != nil {
+		t.Errorf("Client.Do of an empty response = %v", err)
+	}
+}
+
+func mustGet(t *testing.T, url string) *http.Response {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+
+	"github.com/zhwei820/errors"
+)
+
+var codeTemplate = template.Must(template.New("code").Funcs(template.FuncMap{
+	"doc": docOf,
+}).Parse(`// Code generated by errgen from {{.Source}}. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	errors "{{.Import}}"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+{{- range .Defs}}
+	// ErrCode{{.Name}} {{doc .}}
+	ErrCode{{.Name}} errors.ErrCode = {{.BizCode}}
+{{- end}}
+)
+
+var _ = errors.MustRegister(
+{{- range .Defs}}
+	errors.CodeSpec{
+		BizCode:  ErrCode{{.Name}}.Int(),
+		GRPCCode: codes.{{.GRPCCode}},
+		HTTPCode: {{.HTTPCode}},
+		{{- if .Messages}}
+		Messages: map[errors.LangType]string{
+			{{- range .Messages}}
+			errors.{{.Const}}: {{printf "%q" .Msg}},
+			{{- end}}
+		},
+		{{- end}}
+		{{- if .Metadata}}
+		Metadata: map[string]string{
+			{{- range .Metadata}}
+			{{printf "%q" .Key}}: {{printf "%q" .Value}},
+			{{- end}}
+		},
+		{{- end}}
+	},
+{{- end}}
+)
+{{range .Defs}}
+// {{.Name}}f returns an error which satisfies Is{{.Name}}(), with a formatted
+// message.
+func {{.Name}}f(format string, args ...interface{}) error {
+	args = append(args[:len(args):len(args)], errors.WithCallerSkip(1){{template "options" .}})
+	return errors.Newf(format, args...)
+}
+
+// New{{.Name}} returns an error which wraps err that satisfies
+// Is{{.Name}}(), with msg as message, as errors.NewNotFound: the error stays
+// the cause, errors.Is sees through it to err.
+func New{{.Name}}(err error, msg string) error {
+	return errors.Newf("%s", msg, errors.WithCause(err), errors.WithCallerSkip(1){{template "options" .}})
+}
+
+// Is{{.Name}} reports whether err was created with {{.Name}}f() or
+// New{{.Name}}().
+func Is{{.Name}}(err error) bool {
+	return errors.IsBizCodeError(err, ErrCode{{.Name}}.Int())
+}
+{{end}}
+{{- define "options"}}, errors.WithGRPCCode(codes.{{.GRPCCode}}), errors.WithHTTPStatus({{.HTTPCode}}), errors.WithBizCode(ErrCode{{.Name}}.Int()), errors.WithRetryable({{.Retryable}}){{end}}`))
+
+var catalogTemplate = template.Must(template.New("catalog").Funcs(template.FuncMap{
+	"cell": markdownCell,
+}).Parse(`<!-- Code generated by errgen from {{.Source}}. DO NOT EDIT. -->
+
+# {{.Package}} error codes
+
+| Name | Biz code | gRPC code | HTTP status | Retryable |{{range .Langs}} {{.}} |{{end}} Description |
+|---|---|---|---|---|{{range .Langs}}---|{{end}}---|
+{{- range $def := .Defs}}
+| {{$def.Name}} | {{$def.BizCode}} | {{$def.GRPCCode}} | {{$def.HTTPCode}} | {{if $def.Retryable}}yes{{else}}no{{end}} |{{range $.Langs}} {{cell ($def.Message .)}} |{{end}} {{cell $def.Description}} |
+{{- end}}
+`))
+
+type templateData struct {
+	Source  string
+	Package string
+	Import  string
+	Defs    []definition
+	Langs   []errors.LangType
+}
+
+// Message returns the message of def in lang, or "".
+func (def definition) Message(lang errors.LangType) string {
+	for _, msg := range def.Messages {
+		if msg.Lang == lang {
+			return msg.Msg
+		}
+	}
+	return ""
+}
+
+// docOf returns the doc comment text of def: its description, or else its
+// English message.
+func docOf(def definition) string {
+	doc := def.Description
+	if doc == "" {
+		doc = def.Message(errors.EnUs)
+	}
+	return strings.Join(strings.Fields(doc), " ")
+}
+
+func markdownCell(s string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(s), " "), "|", `\|`)
+}
+
+func newTemplateData(source, importPath string, spec *Spec, defs []definition) templateData {
+	data := templateData{Source: source, Package: spec.Package, Import: importPath, Defs: defs}
+	for _, lang := range langOrder {
+		for _, def := range defs {
+			if def.Message(lang) != "" {
+				data.Langs = append(data.Langs, lang)
+				break
+			}
+		}
+	}
+	return data
+}
+
+// generateCode returns the formatted Go source of data.
+func generateCode(data templateData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := codeTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// generateCatalog returns the markdown catalog of data.
+func generateCatalog(data templateData) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := catalogTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,70 @@
+// Command errgen generates the error codes of a package from a YAML or JSON
+// spec, see Spec: the ErrCode constants, their declaration in the
+// errors.DefaultRegistry, which also translates their messages, the Xxxf,
+// NewXxx and IsXxx functions of each error, and optionally a markdown
+// catalog of the codes.
+//
+// Usage:
+//
+//	errgen -spec errors.yaml [-out errors_gen.go] [-doc ERRORS.md] [-import github.com/zhwei820/errors]
+//
+// It is meant to be run by go generate:
+//
+//	//go:generate go run github.com/zhwei820/errors/cmd/errgen -spec errors.yaml -doc ERRORS.md
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	var (
+		specPath   = flag.String("spec", "", "path of the YAML or JSON spec of the errors")
+		outPath    = flag.String("out", "", "path of the generated Go file, defaults to the spec path with the _gen.go suffix")
+		docPath    = flag.String("doc", "", "path of the generated markdown catalog, none by default")
+		importPath = flag.String("import", "github.com/zhwei820/errors", "import path of the errors package")
+	)
+	flag.Parse()
+	if *specPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if *outPath == "" {
+		*outPath = strings.TrimSuffix(*specPath, filepath.Ext(*specPath)) + "_gen.go"
+	}
+	if err := run(*specPath, *outPath, *docPath, *importPath); err != nil {
+		fmt.Fprintln(os.Stderr, "errgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, outPath, docPath, importPath string) error {
+	spec, err := loadSpec(specPath)
+	if err != nil {
+		return err
+	}
+	defs, err := spec.definitions()
+	if err != nil {
+		return fmt.Errorf("%s: %w", specPath, err)
+	}
+	data := newTemplateData(filepath.Base(specPath), importPath, spec, defs)
+	src, err := generateCode(data)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(outPath, src, 0o644); err != nil {
+		return err
+	}
+	if docPath == "" {
+		return nil
+	}
+	catalog, err := generateCatalog(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(docPath, catalog, 0o644)
+}
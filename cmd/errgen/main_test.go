@@ -0,0 +1,141 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSpec = `package: order
+errors:
+  - name: OrderNotFound
+    biz_code: 1200020001
+    grpc_code: NotFound
+    description: the order doesn't exist
+    messages:
+      en-US: order not found
+      zh-CN: 订单不存在
+    metadata:
+      owner: order-team
+  - name: PaymentGatewayDown
+    biz_code: 1200020002
+    grpc_code: Unavailable
+    messages:
+      en-US: payment gateway | down
+`
+
+const testProgram = `package order
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/zhwei820/errors"
+)
+
+func Check() error {
+	defer errors.SetStackCapture(errors.SetStackCapture(true))
+	err := NewOrderNotFound(io.EOF, "order 42")
+	if !IsOrderNotFound(err) || !errors.IsNotFound(err) || IsPaymentGatewayDown(err) {
+		return fmt.Errorf("unexpected predicates of %v", err)
+	}
+	if !errors.Is(err, io.EOF) {
+		return fmt.Errorf("the cause of %v is not io.EOF", err)
+	}
+	annotated := errors.Annotate(NewOrderNotFound(errors.V2MysqlErr(io.EOF), ""), "get order")
+	if !IsOrderNotFound(annotated) || errors.IsInternal(annotated) || !errors.Is(annotated, io.EOF) {
+		return fmt.Errorf("the codes of %v are lost once annotated", annotated)
+	}
+	for _, err := range []error{err, PaymentGatewayDownf("gateway %d", 1)} {
+		codeErr, _ := errors.AsCodeError(err)
+		if file, _ := codeErr.Location(); !strings.HasSuffix(file, "check.go") {
+			return fmt.Errorf("%v located in %s", err, file)
+		}
+		if frames := errors.Frames(err); len(frames) == 0 || !strings.HasSuffix(frames[0].Function, ".Check") {
+			return fmt.Errorf("the stack of %v does not start at the caller: %v", err, frames)
+		}
+	}
+	if !errors.IsRetryable(PaymentGatewayDownf("gateway %d", 1)) {
+		return fmt.Errorf("PaymentGatewayDown is not retryable")
+	}
+	if spec, ok := errors.LookupCode(ErrCodeOrderNotFound.Int()); !ok || spec.Metadata["owner"] != "order-team" {
+		return fmt.Errorf("unexpected spec %+v", spec)
+	}
+	if msg := errors.Translate(errors.ZhCn, ErrCodeOrderNotFound.String()); msg != "订单不存在" {
+		return fmt.Errorf("unexpected translation %q", msg)
+	}
+	return nil
+}
+`
+
+const testMain = `package order
+
+import "testing"
+
+func TestGenerated(t *testing.T) {
+	if err := Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+`
+
+func TestRun(t *testing.T) {
+	// The generated package is compiled within the module.
+	dir, err := os.MkdirTemp(".", "testdata-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	specPath := filepath.Join(dir, "errors.yaml")
+	files := map[string]string{"errors.yaml": testSpec, "check.go": testProgram, "check_test.go": testMain}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	docPath := filepath.Join(dir, "ERRORS.md")
+	if err := run(specPath, filepath.Join(dir, "errors_gen.go"), docPath, "github.com/zhwei820/errors"); err != nil {
+		t.Fatal(err)
+	}
+
+	catalog, err := os.ReadFile(docPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"| Name | Biz code | gRPC code | HTTP status | Retryable | en-US | zh-CN | Description |",
+		"| OrderNotFound | 1200020001 | NotFound | 404 | no | order not found | 订单不存在 | the order doesn't exist |",
+		`| PaymentGatewayDown | 1200020002 | Unavailable | 503 | yes | payment gateway \| down |  |  |`,
+	} {
+		if !strings.Contains(string(catalog), want) {
+			t.Errorf("catalog lacks %q:\n%s", want, catalog)
+		}
+	}
+
+	out, err := exec.Command("go", "test", "./"+dir).CombinedOutput()
+	if err != nil {
+		t.Fatalf("generated package: %v\n%s", err, out)
+	}
+}
+
+func TestDefinitionsErrors(t *testing.T) {
+	tests := []struct {
+		spec Spec
+		want string
+	}{
+		{Spec{Package: "order"}, "no error defined"},
+		{Spec{Package: "order", Errors: []ErrorSpec{{Name: "notExported", BizCode: 1200020001, GRPCCode: "NotFound"}}}, "exported identifier"},
+		{Spec{Package: "order", Errors: []ErrorSpec{{Name: "A", BizCode: 1200020001, GRPCCode: "Missing"}}}, "invalid grpc code"},
+		{Spec{Package: "order", Errors: []ErrorSpec{{Name: "A", BizCode: 1, GRPCCode: "NotFound"}}}, "neither ten nor eight digits"},
+		{Spec{Package: "order", Errors: []ErrorSpec{{Name: "A", BizCode: 1200020001, GRPCCode: "NotFound"}, {Name: "B", BizCode: 1200020001, GRPCCode: "NotFound"}}}, "already registered"},
+		{Spec{Package: "order", Errors: []ErrorSpec{{Name: "A", BizCode: 1200020001, GRPCCode: "NotFound", Messages: map[string]string{"fr-FR": "x"}}}}, "unknown language"},
+	}
+	for _, tt := range tests {
+		if _, err := tt.spec.definitions(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("definitions of %+v = %v, want %q", tt.spec, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/zhwei820/errors"
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the definition of the errors of a package, e.g.
+//
+//	package: order
+//	errors:
+//	  - name: OrderNotFound
+//	    biz_code: 1200020001
+//	    grpc_code: NotFound
+//	    description: the order doesn't exist or was deleted
+//	    messages:
+//	      en-US: order not found
+//	      zh-CN: 订单不存在
+//	    metadata:
+//	      owner: order-team
+type Spec struct {
+	Package string      `json:"package" yaml:"package"`
+	Errors  []ErrorSpec `json:"errors" yaml:"errors"`
+}
+
+// ErrorSpec is the definition of an error. The http status defaults to the
+// one of the grpc code, the error is retryable by default if its grpc code is
+// Unavailable or Aborted.
+type ErrorSpec struct {
+	Name        string            `json:"name" yaml:"name"`
+	BizCode     uint32            `json:"biz_code" yaml:"biz_code"`
+	GRPCCode    string            `json:"grpc_code" yaml:"grpc_code"`
+	HTTPStatus  uint32            `json:"http_status" yaml:"http_status"`
+	Retryable   *bool             `json:"retryable" yaml:"retryable"`
+	Description string            `json:"description" yaml:"description"`
+	Messages    map[string]string `json:"messages" yaml:"messages"`
+	Metadata    map[string]string `json:"metadata" yaml:"metadata"`
+}
+
+// langConsts maps the language tags to the name of their LangType constant.
+var langConsts = map[errors.LangType]string{
+	errors.ZhCn: "ZhCn",
+	errors.EnUs: "EnUs",
+	errors.RuRu: "RuRu",
+	errors.ZhTW: "ZhTW",
+	errors.JaJP: "JaJP",
+	errors.KoKR: "KoKR",
+	errors.ESES: "ESES",
+	errors.DEDE: "DEDE",
+}
+
+// langOrder is the order of the languages in the generated code and catalog.
+var langOrder = []errors.LangType{errors.EnUs, errors.ZhCn, errors.ZhTW, errors.RuRu, errors.JaJP, errors.KoKR, errors.ESES, errors.DEDE}
+
+// definition is a validated ErrorSpec.
+type definition struct {
+	Name        string
+	BizCode     uint32
+	GRPCCode    codes.Code
+	HTTPCode    uint32
+	Retryable   bool
+	Description string
+	Messages    []message
+	Metadata    []metadata
+}
+
+type message struct {
+	Lang  errors.LangType
+	Const string
+	Msg   string
+}
+
+type metadata struct {
+	Key, Value string
+}
+
+// loadSpec reads the spec at path, as YAML unless its extension is .json.
+func loadSpec(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var spec Spec
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &spec)
+	} else {
+		err = yaml.Unmarshal(data, &spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return &spec, nil
+}
+
+// definitions validates the errors of spec. The biz codes are validated by
+// registering them in a new errors.Registry, which also fills in the default
+// http statuses.
+func (spec *Spec) definitions() ([]definition, error) {
+	if !token.IsIdentifier(spec.Package) {
+		return nil, fmt.Errorf("invalid package name %q", spec.Package)
+	}
+	if len(spec.Errors) == 0 {
+		return nil, fmt.Errorf("no error defined")
+	}
+	registry := errors.NewRegistry()
+	names := map[string]bool{}
+	defs := make([]definition, 0, len(spec.Errors))
+	for _, e := range spec.Errors {
+		if !token.IsIdentifier(e.Name) || !token.IsExported(e.Name) {
+			return nil, fmt.Errorf("invalid error name %q, it must be an exported identifier", e.Name)
+		}
+		if names[e.Name] {
+			return nil, fmt.Errorf("error %s defined twice", e.Name)
+		}
+		names[e.Name] = true
+		code, err := parseGRPCCode(e.GRPCCode)
+		if err != nil {
+			return nil, fmt.Errorf("error %s: %w", e.Name, err)
+		}
+		if err := registry.Register(errors.CodeSpec{BizCode: e.BizCode, GRPCCode: code, HTTPCode: e.HTTPStatus}); err != nil {
+			return nil, fmt.Errorf("error %s: %w", e.Name, err)
+		}
+		registered, _ := registry.Lookup(e.BizCode)
+		def := definition{
+			Name:        e.Name,
+			BizCode:     e.BizCode,
+			GRPCCode:    code,
+			HTTPCode:    registered.HTTPCode,
+			Retryable:   code == codes.Unavailable || code == codes.Aborted,
+			Description: e.Description,
+		}
+		if e.Retryable != nil {
+			def.Retryable = *e.Retryable
+		}
+		for tag := range e.Messages {
+			if _, ok := langConsts[errors.LangType(tag)]; !ok {
+				return nil, fmt.Errorf("error %s: unknown language %q", e.Name, tag)
+			}
+		}
+		for _, lang := range langOrder {
+			if msg, ok := e.Messages[string(lang)]; ok {
+				def.Messages = append(def.Messages, message{Lang: lang, Const: langConsts[lang], Msg: msg})
+			}
+		}
+		for key, value := range e.Metadata {
+			def.Metadata = append(def.Metadata, metadata{key, value})
+		}
+		sort.Slice(def.Metadata, func(i, k int) bool { return def.Metadata[i].Key < def.Metadata[k].Key })
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// parseGRPCCode parses the name of a grpc code as returned by
+// codes.Code.String, e.g. "NotFound".
+func parseGRPCCode(name string) (codes.Code, error) {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid grpc code %q", name)
+}
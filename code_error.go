@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"google.golang.org/grpc/codes"
 )
@@ -11,17 +12,46 @@ import (
 type ErrCode uint32
 
 const (
+	ErrCodeCanceled            ErrCode = 12000001 // 499
+	ErrCodeUnknown             ErrCode = 12000002
 	ErrCodeBadRequest          ErrCode = 12000003
+	ErrCodeDeadlineExceeded    ErrCode = 12000004 // 504
 	ErrCodeNotFound            ErrCode = 12000005
 	ErrCodeConflict            ErrCode = 12000006
 	ErrCodeForbidden           ErrCode = 12000007 // 403
+	ErrCodeResourceExhausted   ErrCode = 12000008 // 429
 	ErrCodePreconditionFailed  ErrCode = 12000009
+	ErrCodeAborted             ErrCode = 12000010 // 409
+	ErrCodeOutOfRange          ErrCode = 12000011
 	ErrCodeNotImplemented      ErrCode = 12000012
 	ErrCodeInternalServerError ErrCode = 12000013
 	ErrCodeServiceUnavailable  ErrCode = 12000014
+	ErrCodeDataLoss            ErrCode = 12000015
 	ErrCodeUnauthorized        ErrCode = 12000016 // 401
 )
 
+// StatusClientClosedRequest is the non standard http status of the Canceled
+// errors, used when the client cancels the request, as popularized by nginx.
+// StatusTextClientClosedRequest is its text, unknown to http.StatusText.
+const (
+	StatusClientClosedRequest     = 499
+	StatusTextClientClosedRequest = "Client Closed Request"
+)
+
+// statusText returns the text of the http status, see http.StatusText.
+func statusText(status uint32) string {
+	if status == StatusClientClosedRequest {
+		return StatusTextClientClosedRequest
+	}
+	return http.StatusText(int(status))
+}
+
+// statusSuffix returns the message suffix of the errors of code: the text of
+// its http status in the DefaultCodeTable.
+func statusSuffix(code codes.Code) string {
+	return " " + statusText(DefaultCodeTable.HTTPStatus(code))
+}
+
 func (c ErrCode) String() string {
 	return strconv.Itoa(int(c))
 }
@@ -29,107 +59,193 @@ func (c ErrCode) Int() uint32 {
 	return uint32(c)
 }
 
-func InitI18n() {
-	RegisterI18n([]TransInfo{
-		{
-			Lang: EnUs,
-			Key:  ErrCodeBadRequest.String(),
-			Msg:  "parameter error",
-		},
-		{
-			Lang: ZhCn,
-			Key:  ErrCodeBadRequest.String(),
-			Msg:  "参数错误",
-		},
-		// =================================================================
-		{
-			Lang: EnUs,
-			Key:  ErrCodeNotFound.String(),
-			Msg:  "record not found",
-		},
-		{
-			Lang: ZhCn,
-			Key:  ErrCodeNotFound.String(),
-			Msg:  "未找到",
-		},
-		// =================================================================
-		{
-			Lang: EnUs,
-			Key:  ErrCodeConflict.String(),
-			Msg:  "内部错误",
-		},
-		{
-			Lang: ZhCn,
-			Key:  ErrCodeConflict.String(),
-			Msg:  "内部错误",
-		},
-		// =================================================================
-		{
-			Lang: EnUs,
-			Key:  ErrCodeForbidden.String(),
-			Msg:  "forbidden",
-		},
-		{
-			Lang: ZhCn,
-			Key:  ErrCodeForbidden.String(),
-			Msg:  "没有权限,禁止访问",
+// presetCodes declares the preset biz codes, registered in the
+// DefaultRegistry. Their http statuses follow the DefaultCodeTable, see
+// LookupCode.
+var presetCodes = []CodeSpec{
+	{
+		BizCode:  ErrCodeBadRequest.Int(),
+		GRPCCode: codes.InvalidArgument,
+		HTTPCode: http.StatusBadRequest,
+		Messages: map[LangType]string{EnUs: "parameter error", ZhCn: "参数错误"},
+	},
+	{
+		BizCode:  ErrCodeNotFound.Int(),
+		GRPCCode: codes.NotFound,
+		HTTPCode: http.StatusNotFound,
+		Messages: map[LangType]string{EnUs: "record not found", ZhCn: "未找到"},
+	},
+	{
+		BizCode:  ErrCodeConflict.Int(),
+		GRPCCode: codes.AlreadyExists,
+		HTTPCode: http.StatusConflict,
+		Messages: map[LangType]string{EnUs: "record already exists", ZhCn: "记录已存在"},
+	},
+	{
+		BizCode:  ErrCodeForbidden.Int(),
+		GRPCCode: codes.PermissionDenied,
+		HTTPCode: http.StatusForbidden,
+		Messages: map[LangType]string{EnUs: "forbidden", ZhCn: "没有权限,禁止访问"},
+	},
+	{
+		BizCode:  ErrCodePreconditionFailed.Int(),
+		GRPCCode: codes.FailedPrecondition,
+		HTTPCode: http.StatusPreconditionFailed,
+		Messages: map[LangType]string{EnUs: "precondition error", ZhCn: "前置条件错误"},
+	},
+	{
+		BizCode:  ErrCodeAborted.Int(),
+		GRPCCode: codes.Aborted,
+		HTTPCode: http.StatusConflict,
+		Messages: map[LangType]string{
+			EnUs: "operation aborted, please retry",
+			ZhCn: "操作已中止,请重试",
+			ZhTW: "操作已中止,請重試",
+			RuRu: "операция прервана, повторите попытку",
+			JaJP: "操作が中断されました。再試行してください",
+			KoKR: "작업이 중단되었습니다. 다시 시도하세요",
+			ESES: "operación abortada, vuelva a intentarlo",
+			DEDE: "Vorgang abgebrochen, bitte erneut versuchen",
 		},
-		// =================================================================
-		{
-			Lang: EnUs,
-			Key:  ErrCodePreconditionFailed.String(),
-			Msg:  "precondition error",
+	},
+	{
+		BizCode:  ErrCodeNotImplemented.Int(),
+		GRPCCode: codes.Unimplemented,
+		HTTPCode: http.StatusNotImplemented,
+		Messages: map[LangType]string{EnUs: "not implemented", ZhCn: "未实现"},
+	},
+	{
+		BizCode:  ErrCodeInternalServerError.Int(),
+		GRPCCode: codes.Internal,
+		HTTPCode: http.StatusInternalServerError,
+		Messages: map[LangType]string{EnUs: "internal server error", ZhCn: "内部错误,请稍后重试,或者联系管理员"},
+	},
+	{
+		BizCode:  ErrCodeServiceUnavailable.Int(),
+		GRPCCode: codes.Unavailable,
+		HTTPCode: http.StatusServiceUnavailable,
+		Messages: map[LangType]string{EnUs: "service unavailable", ZhCn: "服务不可用"},
+	},
+	{
+		BizCode:  ErrCodeUnauthorized.Int(),
+		GRPCCode: codes.Unauthenticated,
+		HTTPCode: http.StatusUnauthorized,
+		Messages: map[LangType]string{EnUs: "Unauthorized", ZhCn: "未登录"},
+	},
+	{
+		BizCode:  ErrCodeCanceled.Int(),
+		GRPCCode: codes.Canceled,
+		HTTPCode: StatusClientClosedRequest,
+		Messages: map[LangType]string{
+			EnUs: "request canceled",
+			ZhCn: "请求已取消",
+			ZhTW: "請求已取消",
+			RuRu: "запрос отменён",
+			JaJP: "リクエストはキャンセルされました",
+			KoKR: "요청이 취소되었습니다",
+			ESES: "solicitud cancelada",
+			DEDE: "Anfrage abgebrochen",
 		},
-		{
-			Lang: ZhCn,
-			Key:  ErrCodePreconditionFailed.String(),
-			Msg:  "前置条件错误",
+	},
+	{
+		BizCode:  ErrCodeUnknown.Int(),
+		GRPCCode: codes.Unknown,
+		HTTPCode: http.StatusInternalServerError,
+		Messages: map[LangType]string{
+			EnUs: "unknown error",
+			ZhCn: "未知错误",
+			ZhTW: "未知錯誤",
+			RuRu: "неизвестная ошибка",
+			JaJP: "不明なエラー",
+			KoKR: "알 수 없는 오류",
+			ESES: "error desconocido",
+			DEDE: "unbekannter Fehler",
 		},
-		// =================================================================
-		{
-			Lang: EnUs,
-			Key:  ErrCodeNotImplemented.String(),
-			Msg:  "not implemented",
+	},
+	{
+		BizCode:  ErrCodeDeadlineExceeded.Int(),
+		GRPCCode: codes.DeadlineExceeded,
+		HTTPCode: http.StatusGatewayTimeout,
+		Messages: map[LangType]string{
+			EnUs: "request timed out",
+			ZhCn: "请求超时",
+			ZhTW: "請求逾時",
+			RuRu: "время ожидания запроса истекло",
+			JaJP: "リクエストがタイムアウトしました",
+			KoKR: "요청 시간이 초과되었습니다",
+			ESES: "tiempo de espera agotado",
+			DEDE: "Zeitüberschreitung der Anfrage",
 		},
-		{
-			Lang: ZhCn,
-			Key:  ErrCodeNotImplemented.String(),
-			Msg:  "未实现",
+	},
+	{
+		BizCode:  ErrCodeResourceExhausted.Int(),
+		GRPCCode: codes.ResourceExhausted,
+		HTTPCode: http.StatusTooManyRequests,
+		Messages: map[LangType]string{
+			EnUs: "too many requests",
+			ZhCn: "请求过于频繁",
+			ZhTW: "請求過於頻繁",
+			RuRu: "слишком много запросов",
+			JaJP: "リクエストが多すぎます",
+			KoKR: "요청이 너무 많습니다",
+			ESES: "demasiadas solicitudes",
+			DEDE: "zu viele Anfragen",
 		},
-		// =================================================================
-		{
-			Lang: EnUs,
-			Key:  ErrCodeInternalServerError.String(),
-			Msg:  "internal server error",
+	},
+	{
+		BizCode:  ErrCodeOutOfRange.Int(),
+		GRPCCode: codes.OutOfRange,
+		HTTPCode: http.StatusBadRequest,
+		Messages: map[LangType]string{
+			EnUs: "out of range",
+			ZhCn: "超出范围",
+			ZhTW: "超出範圍",
+			RuRu: "вне допустимого диапазона",
+			JaJP: "範囲外です",
+			KoKR: "범위를 벗어났습니다",
+			ESES: "fuera de rango",
+			DEDE: "außerhalb des gültigen Bereichs",
 		},
-		{
-			Lang: ZhCn,
-			Key:  ErrCodeInternalServerError.String(),
-			Msg:  "内部错误,请稍后重试,或者联系管理员",
+	},
+	{
+		BizCode:  ErrCodeDataLoss.Int(),
+		GRPCCode: codes.DataLoss,
+		HTTPCode: http.StatusInternalServerError,
+		Messages: map[LangType]string{
+			EnUs: "data loss",
+			ZhCn: "数据丢失",
+			ZhTW: "資料遺失",
+			RuRu: "потеря данных",
+			JaJP: "データが失われました",
+			KoKR: "데이터가 손실되었습니다",
+			ESES: "pérdida de datos",
+			DEDE: "Datenverlust",
 		},
-		// =================================================================
-		{
-			Lang: EnUs,
-			Key:  ErrCodeServiceUnavailable.String(),
-			Msg:  "service unavailable",
-		},
-		{
-			Lang: ZhCn,
-			Key:  ErrCodeServiceUnavailable.String(),
-			Msg:  "服务不可用",
-		},
-		// =================================================================
-		{
-			Lang: EnUs,
-			Key:  ErrCodeUnauthorized.String(),
-			Msg:  "Unauthorized",
-		},
-		{
-			Lang: ZhCn,
-			Key:  ErrCodeUnauthorized.String(),
-			Msg:  "未登录",
-		},
-	})
+	},
+}
+
+var _ = MustRegister(presetCodes...)
+
+// presetBizCodes is the set of the biz codes of presetCodes.
+var presetBizCodes = func() map[uint32]bool {
+	set := make(map[uint32]bool, len(presetCodes))
+	for _, spec := range presetCodes {
+		set[spec.BizCode] = true
+	}
+	return set
+}()
+
+// InitI18n registers the messages of the preset biz codes as translations,
+// see RegisterI18n. It is called at init time, calling it again restores the
+// preset messages overridden by RegisterI18n.
+func InitI18n() {
+	var messages []TransInfo
+	for _, spec := range presetCodes {
+		for lang, msg := range spec.Messages {
+			messages = append(messages, TransInfo{Lang: lang, Key: strconv.FormatUint(uint64(spec.BizCode), 10), Msg: msg})
+		}
+	}
+	RegisterI18n(messages)
 }
 
 // =================================================================
@@ -138,18 +254,101 @@ func InitI18n() {
 func wrap(err error, format, suffix string, args ...interface{}) Err {
 	newErr := Err{
 		message:  fmt.Sprintf(format+suffix, args...),
+		format:   format + suffix,
 		previous: err,
 	}
 	newErr.SetLocation(2)
+	newErr.captureStack(2)
 	return newErr
 }
 
+// maxChainDepth bounds the number of errors AsCodeError descends through.
+const maxChainDepth = 100
+
+// AsCodeError finds the first *CodeError in the error chain of err. The chain
+// is walked depth first through Cause(), Unwrap() error, Unwrap() []error and
+// Underlying(), so a *CodeError wrapped by fmt.Errorf("%w"), errors.Join or
+// used as the previous error of Wrap is found as well, unless it is hidden by
+// Mask or Maskf. When several errors are wrapped at once, e.g. by a
+// *MultiError, the most severe *CodeError is returned, see SetSeverityOrder.
+func AsCodeError(err error) (*CodeError, bool) {
+	inner := findCodeError(err, 0)
+	return inner, inner != nil
+}
+
+func findCodeError(err error, depth int) *CodeError {
+	if err == nil || depth > maxChainDepth {
+		return nil
+	}
+	switch e := err.(type) {
+	case *CodeError:
+		return e
+	case multiUnwrapper:
+		return dominantCodeError(e.Unwrap(), depth+1)
+	}
+	if isMasked(err) {
+		return nil
+	}
+	var next []error
+	if e, ok := err.(causer); ok {
+		next = append(next, e.Cause())
+	}
+	if e, ok := err.(interface{ Unwrap() error }); ok {
+		next = append(next, e.Unwrap())
+	}
+	if e, ok := err.(wrapper); ok {
+		next = append(next, e.Underlying())
+	}
+	for _, e := range next {
+		if inner := findCodeError(e, depth+1); inner != nil {
+			return inner
+		}
+	}
+	return nil
+}
+
+// isMasked reports whether err hides the errors it was created from, like the
+// errors returned by Mask and Maskf: it has no cause of its own but a
+// previous error.
+func isMasked(err error) bool {
+	c, ok := err.(causer)
+	if !ok || c.Cause() != nil {
+		return false
+	}
+	w, ok := err.(wrapper)
+	return ok && w.Underlying() != nil
+}
+
+// dominantCodeError returns the most severe *CodeError found in errs. When
+// several errors are equally severe, the first one wins.
+func dominantCodeError(errs []error, depth int) *CodeError {
+	var dominant *CodeError
+	for _, err := range errs {
+		inner := findCodeError(err, depth)
+		if inner != nil && (dominant == nil || severity(inner.Code) < severity(dominant.Code)) {
+			dominant = inner
+		}
+	}
+	return dominant
+}
+
 // CodeError represents an error on timeout.
 type CodeError struct {
 	Err
 	Code     codes.Code
 	HTTPCode uint32
 	BizCode  uint32 // custom biz code
+
+	// PublicMessage is the message shown to clients, the error message itself
+	// is the private detail, see GetPublicMessage.
+	PublicMessage string
+
+	// Retryable tells callers the failed call is safe to retry, after
+	// RetryAfter if it is set.
+	Retryable  bool
+	RetryAfter time.Duration
+
+	bizCodeError bool // created by NewBizCodeError, see IsAnyBizCodeErr
 }
 
 // GetGRPCCode return grpc code
@@ -167,30 +366,76 @@ func (c *CodeError) GetBizCode() uint32 {
 	return c.BizCode
 }
 
+// GetPublicMessage returns the message which is safe to show to clients: the
+// PublicMessage if set. Otherwise client errors (http code below 500) show
+// their own message, without the errors they wrap, and server errors only
+// show the http status text, so that internal details never leak.
+func (c *CodeError) GetPublicMessage() string {
+	if c.PublicMessage != "" {
+		return c.PublicMessage
+	}
+	if c.HTTPCode < http.StatusInternalServerError && c.message != "" {
+		return c.message
+	}
+	return statusText(c.HTTPCode)
+}
+
+// Unwrap returns the error wrapped by c, see WithCause, so that errors.Is
+// and errors.As see through c while c stays the Cause.
+func (c *CodeError) Unwrap() error {
+	if c.cause != nil {
+		return c.cause
+	}
+	return c.previous
+}
+
+// GetDetail returns the private detail of the error: the complete error
+// message, including the errors it wraps.
+func (c *CodeError) GetDetail() string {
+	return c.Error()
+}
+
+// PublicMessage returns the message of err which is safe to show to clients,
+// see CodeError.GetPublicMessage. Errors which aren't a CodeError show the
+// status text of http.StatusInternalServerError.
+func PublicMessage(err error) string {
+	if inner, ok := AsCodeError(err); ok {
+		return inner.GetPublicMessage()
+	}
+	return http.StatusText(http.StatusInternalServerError)
+}
+
+// hasPublicMessage reports whether err is a CodeError with an explicit
+// PublicMessage, which is responded as is instead of the translation of its
+// biz code.
+func hasPublicMessage(err error) bool {
+	inner, ok := AsCodeError(err)
+	return ok && inner.PublicMessage != ""
+}
+
 // NewCodeError new code error
 func NewCodeError(code codes.Code, httpCode, bizCode uint32) error {
-	return &CodeError{Err: wrap(nil, "", ""), Code: code, HTTPCode: httpCode, BizCode: bizCode}
+	return newCodeError(1, "", "", nil, WithGRPCCode(code), WithHTTPStatus(httpCode), WithBizCode(bizCode))
 }
 
 // NewCodeErrorf new code errorf
 func NewCodeErrorf(code codes.Code, httpCode, bizCode uint32, format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, "", args...), Code: code, HTTPCode: httpCode, BizCode: bizCode}
+	return newCodeError(1, format, "", args, WithGRPCCode(code), WithHTTPStatus(httpCode), WithBizCode(bizCode))
 }
 
 // NotValidf returns an error which satisfies IsNotValid().
 func NotValidf(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusBadRequest), args...), Code: codes.InvalidArgument, HTTPCode: http.StatusBadRequest, BizCode: ErrCodeBadRequest.Int()}
+	return newCodeError(1, format, statusSuffix(codes.InvalidArgument), args, WithGRPCCode(codes.InvalidArgument))
 }
 
 // NewNotValid returns an error which wraps err and satisfies IsNotValid().
 func NewNotValid(err error, msg string) error {
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.InvalidArgument, HTTPCode: http.StatusBadRequest, BizCode: ErrCodeBadRequest.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.InvalidArgument), WithCause(err))
 }
 
 // IsNotValid is not valid error
 func IsNotValid(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.InvalidArgument
 	}
 	return false
@@ -198,18 +443,17 @@ func IsNotValid(err error) bool {
 
 // NotFoundf returns an error which satisfies IsNotFound().
 func NotFoundf(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusNotFound), args...), Code: codes.NotFound, HTTPCode: http.StatusNotFound, BizCode: ErrCodeNotFound.Int()}
+	return newCodeError(1, format, statusSuffix(codes.NotFound), args, WithGRPCCode(codes.NotFound))
 }
 
 // NewNotFound returns an error which wraps err that satisfies
 func NewNotFound(err error, msg string) error {
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.NotFound, HTTPCode: http.StatusNotFound, BizCode: ErrCodeNotFound.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.NotFound), WithCause(err))
 }
 
 // IsNotFound is not Fund
 func IsNotFound(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.NotFound
 	}
 	return false
@@ -217,18 +461,17 @@ func IsNotFound(err error) bool {
 
 // AlreadyExistsf returns an error which satisfies
 func AlreadyExistsf(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusConflict), args...), Code: codes.AlreadyExists, HTTPCode: http.StatusConflict, BizCode: ErrCodeConflict.Int()}
+	return newCodeError(1, format, statusSuffix(codes.AlreadyExists), args, WithGRPCCode(codes.AlreadyExists))
 }
 
 // NewAlreadyExists returns an error which wraps err and satisfies
 func NewAlreadyExists(err error, msg string) error {
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.AlreadyExists, HTTPCode: http.StatusConflict, BizCode: ErrCodeConflict.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.AlreadyExists), WithCause(err))
 }
 
 // IsAlreadyExists is already exists
 func IsAlreadyExists(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.AlreadyExists
 	}
 	return false
@@ -236,18 +479,17 @@ func IsAlreadyExists(err error) bool {
 
 // Forbiddenf returns an error which satistifes IsForbidden()
 func Forbiddenf(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusForbidden), args...), Code: codes.PermissionDenied, HTTPCode: http.StatusForbidden, BizCode: ErrCodeForbidden.Int()}
+	return newCodeError(1, format, statusSuffix(codes.PermissionDenied), args, WithGRPCCode(codes.PermissionDenied))
 }
 
 // NewForbidden returns an error which wraps err that satisfies
 func NewForbidden(err error, msg string) error {
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.PermissionDenied, HTTPCode: http.StatusForbidden, BizCode: ErrCodeForbidden.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.PermissionDenied), WithCause(err))
 }
 
 // IsForbidden is forbidden error
 func IsForbidden(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.PermissionDenied
 	}
 	return false
@@ -255,18 +497,17 @@ func IsForbidden(err error) bool {
 
 // FailedPreconditionf returns an error which satisfaction IsFailedPrecondition()
 func FailedPreconditionf(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusPreconditionFailed), args...), Code: codes.FailedPrecondition, HTTPCode: http.StatusPreconditionFailed, BizCode: ErrCodePreconditionFailed.Int()}
+	return newCodeError(1, format, statusSuffix(codes.FailedPrecondition), args, WithGRPCCode(codes.FailedPrecondition))
 }
 
 // NewFailedPrecondition returns an error which wraps err that satisfies
 func NewFailedPrecondition(err error, msg string) error {
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.FailedPrecondition, HTTPCode: http.StatusPreconditionFailed, BizCode: ErrCodePreconditionFailed.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.FailedPrecondition), WithCause(err))
 }
 
 // IsFailedPrecondition is failed precondition errors
 func IsFailedPrecondition(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.FailedPrecondition
 	}
 	return false
@@ -274,18 +515,17 @@ func IsFailedPrecondition(err error) bool {
 
 // Abortedf returns an error which satisfaction IsAborted()
 func Abortedf(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusInternalServerError), args...), Code: codes.Aborted, HTTPCode: http.StatusInternalServerError, BizCode: ErrCodeInternalServerError.Int()}
+	return newCodeError(1, format, statusSuffix(codes.Aborted), args, WithGRPCCode(codes.Aborted))
 }
 
 // NewAborted returns an error which wraps err that satisfies
 func NewAborted(err error, msg string) error {
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.Aborted, HTTPCode: http.StatusInternalServerError, BizCode: ErrCodeInternalServerError.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.Aborted), WithCause(err))
 }
 
 // IsAborted is aborted error
 func IsAborted(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.Aborted
 	}
 	return false
@@ -293,18 +533,17 @@ func IsAborted(err error) bool {
 
 // NotImplementedf returns an error which satisfies IsNotImplemented().
 func NotImplementedf(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusNotImplemented), args...), Code: codes.Unimplemented, HTTPCode: http.StatusNotImplemented, BizCode: ErrCodeNotImplemented.Int()}
+	return newCodeError(1, format, statusSuffix(codes.Unimplemented), args, WithGRPCCode(codes.Unimplemented))
 }
 
 // NewNotImplemented returns an error which wraps err and satisfies
 func NewNotImplemented(err error, msg string) error {
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.Unimplemented, HTTPCode: http.StatusNotImplemented, BizCode: ErrCodeNotImplemented.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.Unimplemented), WithCause(err))
 }
 
 // IsNotImplemented is not implemented
 func IsNotImplemented(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.Unimplemented
 	}
 	return false
@@ -312,21 +551,20 @@ func IsNotImplemented(err error) bool {
 
 // Internalf returns an error which internal server error
 func Internalf(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusInternalServerError), args...), Code: codes.Internal, HTTPCode: http.StatusInternalServerError, BizCode: ErrCodeInternalServerError.Int()}
+	return newCodeError(1, format, statusSuffix(codes.Internal), args, WithGRPCCode(codes.Internal))
 }
 
 // NewInternal == NewInternal return an error which internal server error
 func NewInternal(err error, msg string) error {
 	if IsBizCodeError(err, MysqlErrorBizCode) { // 对于mysql error, bizcode需要设置为 MysqlErrorBizCode
-		return &CodeError{Err: wrap(err, msg, ""), Code: codes.Internal, HTTPCode: http.StatusInternalServerError, BizCode: MysqlErrorBizCode}
+		return newCodeError(1, msg, "", nil, WithGRPCCode(codes.Internal), WithBizCode(MysqlErrorBizCode), WithCause(err))
 	}
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.Internal, HTTPCode: http.StatusInternalServerError, BizCode: ErrCodeInternalServerError.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.Internal), WithCause(err))
 }
 
 // IsInternal is internal error
 func IsInternal(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.Internal
 	}
 	return false
@@ -334,18 +572,17 @@ func IsInternal(err error) bool {
 
 // Unavailablef returns an error which server unavailable
 func Unavailablef(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusServiceUnavailable), args...), Code: codes.Unavailable, HTTPCode: http.StatusServiceUnavailable, BizCode: ErrCodeServiceUnavailable.Int()}
+	return newCodeError(1, format, statusSuffix(codes.Unavailable), args, WithGRPCCode(codes.Unavailable))
 }
 
 // NewUnavailable returns an error which server unavailable
 func NewUnavailable(err error, msg string) error {
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.Unavailable, HTTPCode: http.StatusServiceUnavailable, BizCode: ErrCodeServiceUnavailable.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.Unavailable), WithCause(err))
 }
 
 // IsUnavailable is unavailable error
 func IsUnavailable(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.Unavailable
 	}
 	return false
@@ -353,48 +590,204 @@ func IsUnavailable(err error) bool {
 
 // Unauthorizedf returns an error which satisfies IsUnauthorized().
 func Unauthorizedf(format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, " "+http.StatusText(http.StatusUnauthorized), args...), Code: codes.Unauthenticated, HTTPCode: http.StatusUnauthorized, BizCode: ErrCodeUnauthorized.Int()}
+	return newCodeError(1, format, statusSuffix(codes.Unauthenticated), args, WithGRPCCode(codes.Unauthenticated))
 }
 
 // NewUnauthorized returns an error which wraps err and satisfies
 func NewUnauthorized(err error, msg string) error {
-	return &CodeError{Err: wrap(err, msg, ""), Code: codes.Unauthenticated, HTTPCode: http.StatusUnauthorized, BizCode: ErrCodeUnauthorized.Int()}
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.Unauthenticated), WithCause(err))
 }
 
 // IsUnauthorized is unauthorized
 func IsUnauthorized(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.Code == codes.Unauthenticated
 	}
 	return false
 }
 
+// Canceledf returns an error which satisfies IsCanceled().
+func Canceledf(format string, args ...interface{}) error {
+	return newCodeError(1, format, statusSuffix(codes.Canceled), args, WithGRPCCode(codes.Canceled))
+}
+
+// NewCanceled returns an error which wraps err and satisfies IsCanceled().
+func NewCanceled(err error, msg string) error {
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.Canceled), WithCause(err))
+}
+
+// IsCanceled reports whether err is a Canceled error.
+func IsCanceled(err error) bool {
+	if innerErr, ok := AsCodeError(err); ok {
+		return innerErr.Code == codes.Canceled
+	}
+	return false
+}
+
+// DeadlineExceededf returns an error which satisfies IsDeadlineExceeded().
+func DeadlineExceededf(format string, args ...interface{}) error {
+	return newCodeError(1, format, statusSuffix(codes.DeadlineExceeded), args, WithGRPCCode(codes.DeadlineExceeded))
+}
+
+// NewDeadlineExceeded returns an error which wraps err and satisfies IsDeadlineExceeded().
+func NewDeadlineExceeded(err error, msg string) error {
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.DeadlineExceeded), WithCause(err))
+}
+
+// IsDeadlineExceeded reports whether err is a DeadlineExceeded error.
+func IsDeadlineExceeded(err error) bool {
+	if innerErr, ok := AsCodeError(err); ok {
+		return innerErr.Code == codes.DeadlineExceeded
+	}
+	return false
+}
+
+// ResourceExhaustedf returns an error which satisfies IsResourceExhausted().
+func ResourceExhaustedf(format string, args ...interface{}) error {
+	return newCodeError(1, format, statusSuffix(codes.ResourceExhausted), args, WithGRPCCode(codes.ResourceExhausted))
+}
+
+// NewResourceExhausted returns an error which wraps err and satisfies IsResourceExhausted().
+func NewResourceExhausted(err error, msg string) error {
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.ResourceExhausted), WithCause(err))
+}
+
+// IsResourceExhausted reports whether err is a ResourceExhausted error.
+func IsResourceExhausted(err error) bool {
+	if innerErr, ok := AsCodeError(err); ok {
+		return innerErr.Code == codes.ResourceExhausted
+	}
+	return false
+}
+
+// OutOfRangef returns an error which satisfies IsOutOfRange().
+func OutOfRangef(format string, args ...interface{}) error {
+	return newCodeError(1, format, statusSuffix(codes.OutOfRange), args, WithGRPCCode(codes.OutOfRange))
+}
+
+// NewOutOfRange returns an error which wraps err and satisfies IsOutOfRange().
+func NewOutOfRange(err error, msg string) error {
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.OutOfRange), WithCause(err))
+}
+
+// IsOutOfRange reports whether err is a OutOfRange error.
+func IsOutOfRange(err error) bool {
+	if innerErr, ok := AsCodeError(err); ok {
+		return innerErr.Code == codes.OutOfRange
+	}
+	return false
+}
+
+// DataLossf returns an error which satisfies IsDataLoss().
+func DataLossf(format string, args ...interface{}) error {
+	return newCodeError(1, format, statusSuffix(codes.DataLoss), args, WithGRPCCode(codes.DataLoss))
+}
+
+// NewDataLoss returns an error which wraps err and satisfies IsDataLoss().
+func NewDataLoss(err error, msg string) error {
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.DataLoss), WithCause(err))
+}
+
+// IsDataLoss reports whether err is a DataLoss error.
+func IsDataLoss(err error) bool {
+	if innerErr, ok := AsCodeError(err); ok {
+		return innerErr.Code == codes.DataLoss
+	}
+	return false
+}
+
+// Unknownf returns an error which satisfies IsUnknown().
+func Unknownf(format string, args ...interface{}) error {
+	return newCodeError(1, format, statusSuffix(codes.Unknown), args, WithGRPCCode(codes.Unknown))
+}
+
+// NewUnknown returns an error which wraps err and satisfies IsUnknown().
+func NewUnknown(err error, msg string) error {
+	return newCodeError(1, msg, "", nil, WithGRPCCode(codes.Unknown), WithCause(err))
+}
+
+// IsUnknown reports whether err is a Unknown error.
+func IsUnknown(err error) bool {
+	if innerErr, ok := AsCodeError(err); ok {
+		return innerErr.Code == codes.Unknown
+	}
+	return false
+}
+
 // NewBizCodeError new biz code error with biz code and translated message
+// The grpc code and http status are the ones declared in the DefaultRegistry,
+// codes.OK and http.StatusOK for the undeclared biz codes.
 // Suggest using NewCodeError for more detailed code
 func NewBizCodeError(bizCode uint32) error {
-	return &CodeError{Err: wrap(nil, "", ""), Code: codes.OK, HTTPCode: http.StatusOK, BizCode: bizCode}
+	return newBizCodeError(newCodeError(1, "", "", nil, WithBizCode(bizCode)))
 }
 
 // NewBizCodeErrorf new biz code error with biz code and custom message
+// The grpc code and http status are the ones declared in the DefaultRegistry,
+// codes.OK and http.StatusOK for the undeclared biz codes.
 // Suggest using NewCodeErrorf for more detailed code
 func NewBizCodeErrorf(bizCode uint32, format string, args ...interface{}) error {
-	return &CodeError{Err: wrap(nil, format, "", args...), Code: codes.OK, HTTPCode: http.StatusOK, BizCode: bizCode}
+	return newBizCodeError(newCodeError(1, format, "", args, WithBizCode(bizCode)))
+}
+
+// newBizCodeError marks codeErr as created by NewBizCodeError, the undeclared
+// biz codes are OK.
+func newBizCodeError(codeErr *CodeError) *CodeError {
+	if _, ok := LookupCode(codeErr.BizCode); !ok {
+		codeErr.Code, codeErr.HTTPCode, codeErr.Retryable = codes.OK, http.StatusOK, false
+	}
+	codeErr.bizCodeError = true
+	return codeErr
+}
+
+// retryableByDefault reports whether the failures with the grpc code are safe
+// to retry, unless told otherwise.
+func retryableByDefault(code codes.Code) bool {
+	return code == codes.Unavailable || code == codes.Aborted
+}
+
+// IsRetryable reports whether err is a CodeError which is safe to retry.
+func IsRetryable(err error) bool {
+	if innerErr, ok := AsCodeError(err); ok {
+		return innerErr.Retryable
+	}
+	return false
+}
+
+// RetryAfter returns how long to wait before retrying err, and whether err
+// is retryable at all. A zero duration means the retry may happen at once.
+func RetryAfter(err error) (time.Duration, bool) {
+	if innerErr, ok := AsCodeError(err); ok && innerErr.Retryable {
+		return innerErr.RetryAfter, true
+	}
+	return 0, false
+}
+
+// retryAfterSeconds returns the Retry-After header value of err, or "" if
+// err is not retryable after a delay.
+func retryAfterSeconds(err error) string {
+	delay, ok := RetryAfter(err)
+	if !ok || delay <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(int64((delay+time.Second-1)/time.Second), 10)
 }
 
 // IsBizCodeError is biz code error
 func IsBizCodeError(err error, bizCode uint32) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
 		return innerErr.BizCode == bizCode
 	}
 	return false
 }
 
-// IsAnyBizCodeErr is any biz code error
+// IsAnyBizCodeErr is any biz code error, i.e. an error created by
+// NewBizCodeError or NewBizCodeErrorf, or an OK error with a biz code
 func IsAnyBizCodeErr(err error) bool {
-	err = Cause(err)
-	if innerErr, ok := err.(*CodeError); ok {
+	if innerErr, ok := AsCodeError(err); ok {
+		if innerErr.bizCodeError && innerErr.BizCode > 0 {
+			return true
+		}
 		if innerErr.Code == codes.OK && innerErr.HTTPCode == http.StatusOK && innerErr.BizCode > 0 {
 			return true
 		}
@@ -0,0 +1,145 @@
+package errors
+
+import (
+	stderrors "errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAsCodeError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{"direct", NotFoundf("order")},
+		{"annotated", Annotate(NotFoundf("order"), "load")},
+		{"fmt wrapped", fmt.Errorf("load: %w", NotFoundf("order"))},
+		{"joined", stderrors.Join(io.EOF, NotFoundf("order"))},
+		{"wrap previous", Wrap(NotFoundf("order"), io.EOF)},
+		{"annotated fmt wrapped", Annotate(fmt.Errorf("load: %w", NotFoundf("order")), "handler")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := AsCodeError(tt.err); !ok {
+				t.Fatal("CodeError not found")
+			}
+			if !IsNotFound(tt.err) {
+				t.Fatal("IsNotFound() = false")
+			}
+		})
+	}
+	if _, ok := AsCodeError(Annotate(io.EOF, "read")); ok {
+		t.Fatal("CodeError found in a plain error chain")
+	}
+
+	notFound := NotFoundf("order")
+	if Cause(fmt.Errorf("load: %w", notFound)) != notFound || Cause(fmt.Errorf("read: %w", io.EOF)) != io.EOF {
+		t.Fatal("Cause does not see through fmt.Errorf")
+	}
+}
+
+func TestToGRPCStatus(t *testing.T) {
+	bizErr := NewBizCodeErrorf(1200009904, "insufficient balance")
+	if st := ToGRPCStatus(bizErr); st.Code() != codes.Unknown {
+		t.Fatalf("biz code error status code = %s", st.Code())
+	}
+	if inner, _ := AsCodeError(bizErr); inner.Code != codes.OK {
+		t.Fatalf("ToGRPCStatus changed the code of the error to %s", inner.Code)
+	}
+
+	previous := SetDebugMode(true)
+	defer SetDebugMode(previous)
+	for _, err := range []error{Annotate(Internalf("dial db"), "load order"), Annotate(io.EOF, "load order")} {
+		if got := ToGRPCStatus(err).Message(); got != err.Error() {
+			t.Errorf("debug status message = %q, want %q", got, err.Error())
+		}
+	}
+}
+
+func TestMaskHidesCodeError(t *testing.T) {
+	err := Trace(NotFoundf("order"))
+	if !IsNotFound(err) {
+		t.Fatal("traced CodeError is not detected")
+	}
+	for _, masked := range []error{Mask(err), Maskf(err, "load"), Annotate(Mask(err), "handler")} {
+		if IsNotFound(masked) {
+			t.Fatalf("masked CodeError is detected: %v", masked)
+		}
+	}
+
+	deferred := func() (err error) {
+		defer DeferredAnnotatef(&err, "load %s", "order")
+		return NotFoundf("order")
+	}()
+	if !IsNotFound(deferred) || deferred.Error() != "load order: order Not Found" {
+		t.Fatalf("unexpected deferred annotation %q", deferred)
+	}
+}
+
+func TestRetryable(t *testing.T) {
+	if IsRetryable(NotValidf("name")) || !IsRetryable(Unavailablef("db")) {
+		t.Fatal("unexpected default retryability")
+	}
+
+	err := &CodeError{Err: NewErr("busy"), Code: codes.Unavailable, HTTPCode: 503, BizCode: ErrCodeServiceUnavailable.Int(), Retryable: true, RetryAfter: 1500 * time.Millisecond}
+	restored := GRPCErrToError(ToGRPCReturnError(Annotate(err, "call")))
+	if delay, ok := RetryAfter(restored); !ok || delay != 1500*time.Millisecond {
+		t.Fatalf("RetryAfter() = %v, %v through grpc", delay, ok)
+	}
+	if seconds := retryAfterSeconds(restored); seconds != "2" {
+		t.Fatalf("Retry-After = %q, want 2", seconds)
+	}
+
+	err.Retryable = false
+	if IsRetryable(GRPCErrToError(ToGRPCReturnError(err))) {
+		t.Fatal("non retryable Unavailable error became retryable through grpc")
+	}
+	for _, err := range []error{
+		&CodeError{Err: NewErr("busy"), Code: codes.Unavailable, HTTPCode: 503},
+		New(WithGRPCCode(codes.Aborted), WithBizCode(0), WithRetryable(false)),
+	} {
+		restored, _ := AsCodeError(GRPCErrToError(ToGRPCReturnError(err)))
+		if restored.Retryable || restored.BizCode != 0 {
+			t.Fatalf("non retryable %v without biz code restored as %#v", err, restored)
+		}
+	}
+	if !IsRetryable(GRPCErrToError(status.Error(codes.Unavailable, "connection refused"))) {
+		t.Fatal("plain Unavailable status is not retryable")
+	}
+}
+
+func TestRemainingGRPCCodes(t *testing.T) {
+	tests := []struct {
+		err      error
+		is       func(error) bool
+		code     codes.Code
+		httpCode uint32
+		bizCode  ErrCode
+	}{
+		{Canceledf("query"), IsCanceled, codes.Canceled, 499, ErrCodeCanceled},
+		{NewDeadlineExceeded(io.EOF, "query"), IsDeadlineExceeded, codes.DeadlineExceeded, 504, ErrCodeDeadlineExceeded},
+		{ResourceExhaustedf("quota"), IsResourceExhausted, codes.ResourceExhausted, 429, ErrCodeResourceExhausted},
+		{NewOutOfRange(io.EOF, "page"), IsOutOfRange, codes.OutOfRange, 400, ErrCodeOutOfRange},
+		{DataLossf("block"), IsDataLoss, codes.DataLoss, 500, ErrCodeDataLoss},
+		{NewUnknown(io.EOF, ""), IsUnknown, codes.Unknown, 500, ErrCodeUnknown},
+	}
+	for _, tt := range tests {
+		inner, ok := AsCodeError(tt.err)
+		if !ok || !tt.is(tt.err) || inner.Code != tt.code || inner.HTTPCode != tt.httpCode || inner.BizCode != tt.bizCode.Int() {
+			t.Errorf("unexpected codes of %#v", tt.err)
+		}
+		for _, lang := range []LangType{ZhCn, EnUs, RuRu, ZhTW, JaJP, KoKR, ESES, DEDE} {
+			if _, ok := lookupTranslation(lang, tt.bizCode.String()); !ok {
+				t.Errorf("no %s translation of %s", lang, tt.code)
+			}
+		}
+	}
+	if IsCanceled(NotFoundf("order")) {
+		t.Error("NotFound is canceled")
+	}
+}
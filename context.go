@@ -0,0 +1,142 @@
+package errors
+
+import (
+	"context"
+	"sync"
+)
+
+// Field keys of the identifiers attached to errors by WithContext.
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldSpanID    = "span_id"
+	FieldUserID    = "user_id"
+	FieldTenantID  = "tenant_id"
+)
+
+// ContextExtractor returns the value of an identifier carried by ctx, or ""
+// if ctx doesn't carry it.
+type ContextExtractor func(ctx context.Context) string
+
+type contextFieldsKey struct{}
+
+// ContextWithField returns a copy of ctx carrying the identifier value under
+// key. The identifiers set this way are found by the default extractors, e.g.
+//
+//	ctx = errors.ContextWithField(ctx, errors.FieldRequestID, c.GetHeader("X-Request-ID"))
+func ContextWithField(ctx context.Context, key, value string) context.Context {
+	parent, _ := ctx.Value(contextFieldsKey{}).(map[string]string)
+	fields := make(map[string]string, len(parent)+1)
+	for k, v := range parent {
+		fields[k] = v
+	}
+	fields[key] = value
+	return context.WithValue(ctx, contextFieldsKey{}, fields)
+}
+
+// contextField returns the default extractor of key, which reads the
+// identifiers set by ContextWithField.
+func contextField(key string) ContextExtractor {
+	return func(ctx context.Context) string {
+		fields, _ := ctx.Value(contextFieldsKey{}).(map[string]string)
+		return fields[key]
+	}
+}
+
+var extractors = struct {
+	sync.RWMutex
+	keys []string
+	m    map[string]ContextExtractor
+}{
+	keys: []string{FieldRequestID, FieldTraceID, FieldSpanID, FieldUserID, FieldTenantID},
+	m: map[string]ContextExtractor{
+		FieldRequestID: contextField(FieldRequestID),
+		FieldTraceID:   contextField(FieldTraceID),
+		FieldSpanID:    contextField(FieldSpanID),
+		FieldUserID:    contextField(FieldUserID),
+		FieldTenantID:  contextField(FieldTenantID),
+	},
+}
+
+// RegisterContextExtractor sets the extractor of the identifier stored under
+// key by WithContext, replacing the previous one. It is used to plug in the
+// request ID of a web framework or the trace ID of a tracing library, e.g.
+//
+//	errors.RegisterContextExtractor(errors.FieldTraceID, func(ctx context.Context) string {
+//	    if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+//	        return sc.TraceID().String()
+//	    }
+//	    return ""
+//	})
+//
+// A nil extractor removes the identifier.
+func RegisterContextExtractor(key string, extractor ContextExtractor) {
+	extractors.Lock()
+	defer extractors.Unlock()
+	_, exists := extractors.m[key]
+	switch {
+	case extractor == nil && exists:
+		delete(extractors.m, key)
+		for i, k := range extractors.keys {
+			if k == key {
+				extractors.keys = append(extractors.keys[:i:i], extractors.keys[i+1:]...)
+				break
+			}
+		}
+	case extractor != nil:
+		if !exists {
+			extractors.keys = append(extractors.keys, key)
+		}
+		extractors.m[key] = extractor
+	}
+}
+
+// WithContext attaches the identifiers carried by ctx, such as the request
+// ID and the trace ID, to the error as fields. The identifiers are read by
+// the registered extractors, see RegisterContextExtractor. The location of
+// the WithContext call is recorded. If ctx carries no identifier, err is
+// returned unchanged.
+//
+// For example:
+//
+//	if err := SomeFunc(ctx); err != nil {
+//	    return errors.WithContext(ctx, err)
+//	}
+func WithContext(ctx context.Context, other error) error {
+	if other == nil || ctx == nil {
+		return other
+	}
+	extractors.RLock()
+	var fields map[string]interface{}
+	for _, key := range extractors.keys {
+		if value := extractors.m[key](ctx); value != "" {
+			if fields == nil {
+				fields = map[string]interface{}{}
+			}
+			fields[key] = value
+		}
+	}
+	extractors.RUnlock()
+	if fields == nil {
+		return other
+	}
+	err := &Err{
+		previous: other,
+		cause:    Cause(other),
+		fields:   fields,
+	}
+	err.SetLocation(1)
+	return err
+}
+
+// RequestID returns the request ID attached to err by WithContext, or "".
+func RequestID(err error) string {
+	id, _ := Fields(err)[FieldRequestID].(string)
+	return id
+}
+
+// TraceID returns the trace ID attached to err by WithContext, or "".
+func TraceID(err error) string {
+	id, _ := Fields(err)[FieldTraceID].(string)
+	return id
+}
@@ -0,0 +1,49 @@
+package errors
+
+import (
+	"context"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+func TestWithContext(t *testing.T) {
+	plain := Errorf("no ids")
+	if WithContext(context.Background(), plain) != plain {
+		t.Fatal("error changed although the context carries no identifier")
+	}
+
+	ctx := ContextWithField(context.Background(), FieldRequestID, "req-1")
+	RegisterContextExtractor(FieldTraceID, func(context.Context) string { return "trace-1" })
+	defer RegisterContextExtractor(FieldTraceID, contextField(FieldTraceID))
+
+	err := WithContext(ctx, NotFoundf("order"))
+	if RequestID(err) != "req-1" || TraceID(err) != "trace-1" || !IsNotFound(err) {
+		t.Fatalf("unexpected error %v with fields %v", err, Fields(err))
+	}
+
+	restored := GRPCErrToError(ToGRPCReturnError(err))
+	if RequestID(restored) != "req-1" || TraceID(restored) != "trace-1" {
+		t.Fatalf("IDs are lost through grpc: %v", Fields(restored))
+	}
+	var traced bool
+	for _, detail := range ToGRPCStatus(err).Details() {
+		switch detail := detail.(type) {
+		case *errdetails.RequestInfo:
+			if detail.ServingData != "" {
+				t.Errorf("trace ID sent as serving data %q", detail.ServingData)
+			}
+		case *errdetails.ErrorInfo:
+			traced = true
+			if detail.Metadata[FieldTraceID] != "trace-1" || detail.Reason != "12000005" {
+				t.Errorf("unexpected error info %v", detail)
+			}
+		}
+	}
+	if !traced {
+		t.Error("no error info sent")
+	}
+	if fields := Fields(GRPCErrToError(ToGRPCReturnError(NotFoundf("order")))); fields != nil {
+		t.Errorf("fields %v restored without IDs", fields)
+	}
+}
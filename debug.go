@@ -0,0 +1,48 @@
+package errors
+
+import "sync/atomic"
+
+var debugMode atomic.Bool
+
+// SetDebugMode enables or disables the debug mode of the responders. In debug
+// mode the gin and hertz responders send the complete error message instead
+// of the public one and add a "debug" object holding the error stack and the
+// error fields to the response envelope, and ToGRPCStatus uses the complete
+// error message as status message. It must not be enabled in production as it
+// exposes internal details to the clients. The previous setting is returned.
+func SetDebugMode(enabled bool) bool {
+	return debugMode.Swap(enabled)
+}
+
+// IsDebugMode reports whether the debug mode is enabled.
+func IsDebugMode() bool {
+	return debugMode.Load()
+}
+
+// DebugInfo is the debug detail of an error added to the response envelope
+// in debug mode.
+type DebugInfo struct {
+	Stack  []string               `json:"stack"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// responseMessage returns the message sent to clients for err: its public
+// message, or the complete error message in debug mode.
+func responseMessage(err error) string {
+	if IsDebugMode() {
+		return err.Error()
+	}
+	return PublicMessage(err)
+}
+
+// debugInfo returns the debug detail of err, or nil if the debug mode is
+// disabled.
+func debugInfo(err error) *DebugInfo {
+	if err == nil || !IsDebugMode() {
+		return nil
+	}
+	return &DebugInfo{
+		Stack:  errorStack(err),
+		Fields: Fields(err),
+	}
+}
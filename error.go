@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"reflect"
 	"runtime"
+	"strings"
 )
 
 // Err holds a description of an error along with information about
@@ -18,6 +19,10 @@ type Err struct {
 	// message holds an annotation of the error.
 	message string
 
+	// format holds the format string message was built from, before the
+	// arguments were interpolated, if any.
+	format string
+
 	// cause holds the cause of the error as returned
 	// by the Cause method.
 	cause error
@@ -25,10 +30,66 @@ type Err struct {
 	// previous holds the previous error in the error stack, if any.
 	previous error
 
-	// file and line hold the source code location where the error was
-	// created.
-	file string
-	line int
+	// file, line and function hold the source code location where the
+	// error was created.
+	file     string
+	line     int
+	function string
+
+	// fields holds the structured key/value fields attached by WithFields.
+	fields map[string]interface{}
+
+	// stack holds the call stack captured where the error was created,
+	// if stack capturing was enabled.
+	stack *stack
+}
+
+// NewErr is used to return an Err for the purpose of embedding in other
+// structures.  The location is not specified, and needs to be set with a call
+// to SetLocation.
+//
+// For example:
+//
+//	type FooError struct {
+//	    errors.Err
+//	    code int
+//	}
+//
+//	func NewFooError(code int) error {
+//	    err := &FooError{errors.NewErr("foo"), code}
+//	    err.SetLocation(1)
+//	    return err
+//	}
+func NewErr(format string, args ...interface{}) Err {
+	return Err{
+		message: fmt.Sprintf(format, args...),
+		format:  format,
+	}
+}
+
+// NewErrWithCause is used to return an Err with cause by other error for the
+// purpose of embedding in other structures. The location is not specified,
+// and needs to be set with a call to SetLocation.
+//
+// For example:
+//
+//	type FooError struct {
+//	    errors.Err
+//	    code int
+//	}
+//
+//	func (e *FooError) Annotate(format string, args ...interface{}) error {
+//	    err := &FooError{errors.NewErrWithCause(e.Err, format, args...), e.code}
+//	    err.SetLocation(1)
+//	    return err
+//	}
+func NewErrWithCause(other error, format string, args ...interface{}) Err {
+	return Err{
+		message:  fmt.Sprintf(format, args...),
+		format:   format,
+		cause:    Cause(other),
+		previous: other,
+	}
 }
 
 // Location is the file and line of where the error was most recently
@@ -37,6 +98,12 @@ func (e *Err) Location() (filename string, line int) {
 	return e.file, e.line
 }
 
+// Function is the name of the function where the error was most recently
+// created or annotated, qualified by its package name.
+func (e *Err) Function() string {
+	return e.function
+}
+
 // Underlying returns the previous error in the error stack, if any. A client
 // should not ever really call this method.  It is used to build the error
 // stack and should not be introspected by client calls.  Or more
@@ -91,14 +158,15 @@ func (e *Err) Error() string {
 }
 
 // Format implements fmt.Formatter
-// When printing errors with %+v it also prints the stack trace.
+// When printing errors with %+v it also prints the stack trace, followed by
+// the captured call stack if any.
 // %#v unsurprisingly will print the real underlying type.
 func (e *Err) Format(s fmt.State, verb rune) {
 	switch verb {
 	case 'v':
 		switch {
 		case s.Flag('+'):
-			fmt.Fprintf(s, "%s", ErrorStack(e))
+			fmt.Fprintf(s, "%s%s", ErrorStack(e), formatFrames(Frames(e)))
 			return
 		case s.Flag('#'):
 			// avoid infinite recursion by wrapping e into a type
@@ -124,9 +192,29 @@ func (unformatter) Format() { /* break the fmt.Formatter interface */ }
 // SetLocation records the source location of the error at callDepth stack
 // frames above the call.
 func (e *Err) SetLocation(callDepth int) {
-	_, file, line, _ := runtime.Caller(callDepth + 1)
+	pc, file, line, _ := runtime.Caller(callDepth + 1)
 	e.file = trimSourcePath(file)
 	e.line = line
+	e.function = funcName(pc)
+}
+
+// funcName returns the name of the function containing pc, with the import
+// path of its package stripped, e.g. "pkg.(*Svc).Create".
+func funcName(pc uintptr) string {
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	return shortFuncName(fn.Name())
+}
+
+// shortFuncName strips the import path of the package from the fully
+// qualified function name.
+func shortFuncName(name string) string {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
 }
 
 // StackTrace returns one string for each location recorded in the stack of
@@ -136,8 +224,35 @@ func (e *Err) StackTrace() []string {
 	return errorStack(e)
 }
 
-// Ideally we'd have a way to check identity, but deep equals will do.
+// sameError reports whether e1 and e2 are the same error. Errors are compared
+// by identity: pointer errors are the same when they point to the same value.
+// Errors of other comparable types, such as syscall.Errno, fall back to ==.
+// Errors of incomparable types are never the same, unless both are nil.
 func sameError(e1, e2 error) bool {
-	//nolint
-	return reflect.DeepEqual(e1, e2)
+	if e1 == nil || e2 == nil {
+		return e1 == e2
+	}
+	t := reflect.TypeOf(e1)
+	if t != reflect.TypeOf(e2) {
+		return false
+	}
+	if t.Kind() == reflect.Ptr {
+		return e1 == e2
+	}
+	if !t.Comparable() {
+		return false
+	}
+	return comparableEqual(e1, e2)
+}
+
+// comparableEqual compares e1 and e2 with ==. A comparable struct type may
+// still hold an incomparable value in an interface field, in which case ==
+// panics and the errors are not considered the same.
+func comparableEqual(e1, e2 error) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+	return e1 == e2
 }
@@ -0,0 +1,144 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestErrorStackFunction(t *testing.T) {
+	err := Annotate(NotFoundf("order %d", 42), "load order")
+	lines := errorStack(err)
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), lines)
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, "(errors.TestErrorStackFunction): ") {
+			t.Errorf("line %q misses the function name", line)
+		}
+	}
+}
+
+func TestFields(t *testing.T) {
+	err := WithFields(NotFoundf("order"), "order_id", 42, "user_id", 7)
+	err = Annotate(err, "load order")
+	err = WithFields(err, "order_id", 43)
+
+	fields := Fields(err)
+	if fields["order_id"] != 43 || fields["user_id"] != 7 {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+	if !IsNotFound(err) {
+		t.Fatal("fields hide the cause")
+	}
+	if stack := ErrorStack(err); !strings.Contains(stack, ": [order_id=42 user_id=7]") {
+		t.Fatalf("fields are not rendered in the error stack:\n%s", stack)
+	}
+}
+
+// annotationChain returns an error stack of the given number of layers,
+// alternating annotations and wraps with a new cause.
+func annotationChain(layers int) error {
+	err := NotFoundf("order %d", 42)
+	for i := 0; i < layers; i++ {
+		if i%2 == 0 {
+			err = Annotatef(err, "layer %d", i)
+		} else {
+			err = Wrap(err, Errorf("cause %d", i))
+		}
+	}
+	return err
+}
+
+// valueError is a comparable error type, its cause may hold an
+// incomparable value.
+type valueError struct {
+	msg   string
+	cause error
+}
+
+func (e valueError) Error() string { return e.msg }
+
+// sliceError is an incomparable error type.
+type sliceError []string
+
+func (e sliceError) Error() string { return strings.Join(e, ", ") }
+
+func TestSameError(t *testing.T) {
+	annotated := Annotate(io.EOF, "read")
+	multi := Join(NotFoundf("order"), io.EOF)
+	tests := []struct {
+		name   string
+		e1, e2 error
+		same   bool
+	}{
+		{"nil", nil, nil, true},
+		{"nil and error", nil, io.EOF, false},
+		{"error and nil", io.EOF, nil, false},
+		{"same pointer", annotated, annotated, true},
+		{"equal messages of different causes", Annotate(io.EOF, "read"), Annotate(io.ErrUnexpectedEOF, "read"), false},
+		{"equal messages of the same cause", Annotate(io.EOF, "read"), Annotate(io.EOF, "read"), false},
+		{"equal values", valueError{"read", io.EOF}, valueError{"read", io.EOF}, true},
+		{"equal value messages of different causes", valueError{"read", io.EOF}, valueError{"read", io.ErrUnexpectedEOF}, false},
+		{"values of incomparable causes", valueError{"read", sliceError{"a"}}, valueError{"read", sliceError{"a"}}, false},
+		{"incomparable values", sliceError{"a"}, sliceError{"a"}, false},
+		{"different types", valueError{msg: "EOF"}, io.EOF, false},
+		{"same multi error", multi, multi, true},
+		{"multi errors of the same errors", Join(io.EOF, io.ErrUnexpectedEOF), Join(io.EOF, io.ErrUnexpectedEOF), false},
+		{"nested multi errors", Join(multi, io.EOF), Join(multi, io.EOF), false},
+		{"multi error and its entry", multi, io.EOF, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if same := sameError(tt.e1, tt.e2); same != tt.same {
+				t.Errorf("sameError = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func BenchmarkError(b *testing.B) {
+	for _, layers := range []int{10, 50} {
+		err := annotationChain(layers)
+		b.Run(fmt.Sprintf("%d_layers", layers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = err.Error()
+			}
+		})
+	}
+}
+
+func BenchmarkErrorStack(b *testing.B) {
+	for _, layers := range []int{10, 50} {
+		err := annotationChain(layers)
+		b.Run(fmt.Sprintf("%d_layers", layers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = ErrorStack(err)
+			}
+		})
+	}
+}
+
+// BenchmarkSameError compares two distinct error stacks of equal content,
+// against the reflect.DeepEqual comparison sameError used to rely on.
+func BenchmarkSameError(b *testing.B) {
+	for _, layers := range []int{10, 50} {
+		e1, e2 := annotationChain(layers), annotationChain(layers)
+		b.Run(fmt.Sprintf("%d_layers/identity", layers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = sameError(e1, e2)
+			}
+		})
+		b.Run(fmt.Sprintf("%d_layers/reflect.DeepEqual", layers), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				_ = reflect.DeepEqual(e1, e2)
+			}
+		})
+	}
+}
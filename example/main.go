@@ -31,7 +31,10 @@ func grpcServer() *grpc.Server {
 	if err != nil {
 		panic(err)
 	}
-	grpcSrv := grpc.NewServer()
+	grpcSrv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(errors.UnaryMetricsInterceptor()),
+		grpc.ChainStreamInterceptor(errors.StreamMetricsInterceptor()),
+	)
 	grpc_demo.RegisterDemoServer(grpcSrv, &Demo{})
 	go func() {
 		if err := grpcSrv.Serve(lis); err != nil {
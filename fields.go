@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// badKey is used as the key of a value that has no key, e.g. the last
+// element of an odd-length key/value list.
+const badKey = "!BADKEY"
+
+// WithFields is used to attach structured key/value fields to an existing
+// error. The keyvals alternate between keys and values, keys should be
+// strings. The location of the WithFields call is recorded with the fields.
+//
+// For example:
+//
+//	if err := SomeFunc(); err != nil {
+//	    return errors.WithFields(err, "order_id", id, "user_id", uid)
+//	}
+func WithFields(other error, keyvals ...interface{}) error {
+	if other == nil {
+		return nil
+	}
+	err := &Err{
+		previous: other,
+		cause:    Cause(other),
+		fields:   makeFields(keyvals),
+	}
+	err.SetLocation(1)
+	return err
+}
+
+func makeFields(keyvals []interface{}) map[string]interface{} {
+	if len(keyvals) == 0 {
+		return nil
+	}
+	fields := make(map[string]interface{}, (len(keyvals)+1)/2)
+	for i := 0; i < len(keyvals); i += 2 {
+		if i+1 == len(keyvals) {
+			fields[badKey] = keyvals[i]
+			break
+		}
+		key, ok := keyvals[i].(string)
+		if !ok {
+			key = fmt.Sprint(keyvals[i])
+		}
+		fields[key] = keyvals[i+1]
+	}
+	return fields
+}
+
+// setField attaches the field key to e, unless value is empty.
+func (e *Err) setField(key, value string) {
+	if value == "" {
+		return
+	}
+	if e.fields == nil {
+		e.fields = map[string]interface{}{}
+	}
+	e.fields[key] = value
+}
+
+// Fields returns the fields attached to this error by WithFields, not
+// including the fields of the previous errors in the error stack.
+func (e *Err) Fields() map[string]interface{} {
+	return e.fields
+}
+
+type fielder interface {
+	Fields() map[string]interface{}
+}
+
+var _ fielder = (*Err)(nil)
+
+// Fields returns all the fields attached to the error stack of err, errors
+// wrapped with fmt.Errorf("%w") are walked through as well. When the same key
+// is attached more than once, the outermost value wins. Nil is returned if
+// there are no fields.
+func Fields(err error) map[string]interface{} {
+	var all map[string]interface{}
+	for err != nil {
+		if f, ok := err.(fielder); ok {
+			for k, v := range f.Fields() {
+				if all == nil {
+					all = map[string]interface{}{}
+				}
+				if _, ok := all[k]; !ok {
+					all[k] = v
+				}
+			}
+		}
+		switch w := err.(type) {
+		case wrapper:
+			err = w.Underlying()
+		case interface{ Unwrap() error }:
+			err = w.Unwrap()
+		default:
+			err = nil
+		}
+	}
+	return all
+}
+
+// sortedKeys returns the keys of fields in sorted order.
+func sortedKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatFields renders fields as "[k1=v1 k2=v2]" with the keys sorted.
+func formatFields(fields map[string]interface{}) string {
+	keys := sortedKeys(fields)
+	var b strings.Builder
+	b.WriteString("[")
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%s=%v", k, fields[k])
+	}
+	b.WriteString("]")
+	return b.String()
+}
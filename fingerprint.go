@@ -0,0 +1,102 @@
+package errors
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+)
+
+// FingerprintOption configures Fingerprint.
+type FingerprintOption func(*fingerprintOptions)
+
+type fingerprintOptions struct {
+	withoutLines bool
+}
+
+// FingerprintWithoutLines leaves the line numbers of the recorded locations
+// out of the fingerprint, so that it survives unrelated changes to the
+// source files between deployments.
+func FingerprintWithoutLines() FingerprintOption {
+	return func(o *fingerprintOptions) {
+		o.withoutLines = true
+	}
+}
+
+// Fingerprint returns a stable identifier of err, used to group identical
+// failures together, e.g. in an error tracker. Only the stable parts of the
+// error are hashed: the format strings of the messages before the arguments
+// were interpolated, the recorded locations, the grpc and biz codes of the
+// CodeError and the type and message of the originating error. So the errors
+// returned by
+//
+//	errors.NotFoundf("order %d", id)
+//
+// share the same fingerprint whatever the id.
+func Fingerprint(err error, opts ...FingerprintOption) string {
+	if err == nil {
+		return ""
+	}
+	var o fingerprintOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	h := fnv.New64a()
+	writeFingerprint(h, err, o)
+	if inner, ok := AsCodeError(err); ok {
+		fmt.Fprintf(h, "grpc=%d biz=%d\n", inner.Code, inner.BizCode)
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+type messageFormatter interface {
+	messageFormat() string
+}
+
+// messageFormat returns the format string the message was built from, or
+// the message itself if it was not formatted.
+func (e *Err) messageFormat() string {
+	if e.format != "" {
+		return e.format
+	}
+	return e.message
+}
+
+func writeFingerprint(w io.Writer, err error, o fingerprintOptions) {
+	for err != nil {
+		if merr, ok := err.(multiUnwrapper); ok {
+			for _, child := range merr.Unwrap() {
+				writeFingerprint(w, child, o)
+			}
+			return
+		}
+		if l, ok := err.(locationer); ok {
+			file, line := l.Location()
+			var function string
+			if f, ok := err.(functionLocationer); ok {
+				function = f.Function()
+			}
+			if o.withoutLines {
+				fmt.Fprintf(w, "%s %s\n", trimSourcePath(file), function)
+			} else {
+				fmt.Fprintf(w, "%s:%d %s\n", trimSourcePath(file), line, function)
+			}
+		}
+		switch e := err.(type) {
+		case wrapper:
+			message := e.Message()
+			if f, ok := err.(messageFormatter); ok {
+				message = f.messageFormat()
+			}
+			fmt.Fprintf(w, "%s\n", message)
+			err = e.Underlying()
+		case interface{ Unwrap() error }:
+			// The message of a foreign wrapper such as the one of
+			// fmt.Errorf is already interpolated, only its type is stable.
+			fmt.Fprintf(w, "%T\n", err)
+			err = e.Unwrap()
+		default:
+			fmt.Fprintf(w, "%T %s\n", err, err.Error())
+			err = nil
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package errors
+
+import "testing"
+
+func TestFingerprint(t *testing.T) {
+	notFound := func(id int) error {
+		return Annotate(NotFoundf("order %d", id), "load order")
+	}
+	if Fingerprint(notFound(1)) != Fingerprint(notFound(2)) {
+		t.Fatal("the fingerprint depends on the interpolated arguments")
+	}
+	if Fingerprint(notFound(1)) == Fingerprint(NotFoundf("order %d", 1)) {
+		t.Fatal("the fingerprint ignores the location chain")
+	}
+
+	// Same format on two lines.
+	e1 := Internalf("db down")
+	e2 := Internalf("db down")
+	if Fingerprint(e1) == Fingerprint(e2) {
+		t.Fatal("the fingerprint ignores the line numbers")
+	}
+	if Fingerprint(e1, FingerprintWithoutLines()) != Fingerprint(e2, FingerprintWithoutLines()) {
+		t.Fatal("FingerprintWithoutLines keeps the line numbers")
+	}
+}
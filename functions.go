@@ -16,8 +16,9 @@ import (
 //
 //	return errors.Errorf("validation failed: %s", message)
 func Errorf(format string, args ...interface{}) error {
-	err := &Err{message: fmt.Sprintf(format, args...)}
+	err := &Err{message: fmt.Sprintf(format, args...), format: format}
 	err.SetLocation(1)
+	err.captureStack(1)
 	return err
 }
 
@@ -65,6 +66,7 @@ func Annotatef(other error, format string, args ...interface{}) error {
 		previous: other,
 		cause:    Cause(other),
 		message:  fmt.Sprintf(format, args...),
+		format:   format,
 	}
 	err.SetLocation(1)
 	return err
@@ -99,6 +101,7 @@ func Wrap(other, newDescriptive error) error {
 func Wrapf(other, newDescriptive error, format string, args ...interface{}) error {
 	err := &Err{
 		message:  fmt.Sprintf(format, args...),
+		format:   format,
 		previous: other,
 		cause:    newDescriptive,
 	}
@@ -106,18 +109,98 @@ func Wrapf(other, newDescriptive error, format string, args ...interface{}) erro
 	return err
 }
 
+// Trace adds the location of the Trace call to the stack.  The Cause of the
+// resulting error is the same as the error parameter.  If the other error is
+// nil, the result will be nil.
+//
+// For example:
+//
+//	if err := SomeFunc(); err != nil {
+//	    return errors.Trace(err)
+//	}
+func Trace(other error) error {
+	if other == nil {
+		return nil
+	}
+	err := &Err{previous: other, cause: Cause(other)}
+	err.SetLocation(1)
+	return err
+}
+
+// Maskf masks the given error with the given format string and arguments
+// (like fmt.Sprintf), returning a new error that maintains the error stack,
+// but hides the underlying error type.  The error string still contains the
+// full annotations. If you want to hide the annotations, call Wrap.
+//
+// A masked CodeError is hidden as well: the IsXxx predicates and the
+// responders no longer see it.
+func Maskf(other error, format string, args ...interface{}) error {
+	if other == nil {
+		return nil
+	}
+	err := &Err{
+		message:  fmt.Sprintf(format, args...),
+		format:   format,
+		previous: other,
+	}
+	err.SetLocation(1)
+	return err
+}
+
+// Mask hides the underlying error type, and records the location of the
+// masking.
+func Mask(other error) error {
+	if other == nil {
+		return nil
+	}
+	err := &Err{
+		previous: other,
+	}
+	err.SetLocation(1)
+	return err
+}
+
+// DeferredAnnotatef annotates the given error (when it is not nil) with the
+// given format string and arguments (like fmt.Sprintf). If *err is nil,
+// DeferredAnnotatef does nothing. This method is used in a defer statement in
+// order to annotate any resulting error with the same message.
+//
+// For example:
+//
+//	defer DeferredAnnotatef(&err, "failed to frombulate the %s", arg)
+func DeferredAnnotatef(err *error, format string, args ...interface{}) {
+	if *err == nil {
+		return
+	}
+	newErr := &Err{
+		message:  fmt.Sprintf(format, args...),
+		format:   format,
+		cause:    Cause(*err),
+		previous: *err,
+	}
+	newErr.SetLocation(1)
+	*err = newErr
+}
+
 // Cause returns the cause of the given error.  This will be either the
 // original error, or the result of a Wrap or Mask call.
 //
 // Cause is the usual way to diagnose errors that may have been wrapped by
-// the other errors functions.
+// the other errors functions. The errors which only wrap another one, e.g.
+// with fmt.Errorf("%w"), are seen through.
 func Cause(err error) error {
-	var diag error
-	if err, ok := err.(causer); ok {
-		diag = err.Cause()
-	}
-	if diag != nil {
-		return diag
+	for depth := 0; depth <= maxChainDepth; depth++ {
+		if c, ok := err.(causer); ok {
+			if diag := c.Cause(); diag != nil {
+				return diag
+			}
+			return err
+		}
+		u, ok := err.(interface{ Unwrap() error })
+		if !ok || u.Unwrap() == nil {
+			return err
+		}
+		err = u.Unwrap()
 	}
 	return err
 }
@@ -137,14 +220,29 @@ type wrapper interface {
 	Underlying() error
 }
 
+// multiUnwrapper is implemented by errors wrapping several errors, such as
+// *MultiError and the errors returned by the standard errors.Join.
+type multiUnwrapper interface {
+	Unwrap() []error
+}
+
 type locationer interface {
 	Location() (string, int)
 }
 
+// functionLocationer is a locationer that also knows the name of the
+// function the location belongs to.
+type functionLocationer interface {
+	locationer
+	Function() string
+}
+
 var (
-	_ wrapper    = (*Err)(nil)
-	_ locationer = (*Err)(nil)
-	_ causer     = (*Err)(nil)
+	_ wrapper            = (*Err)(nil)
+	_ locationer         = (*Err)(nil)
+	_ functionLocationer = (*Err)(nil)
+	_ causer             = (*Err)(nil)
+	_ multiUnwrapper     = (*MultiError)(nil)
 )
 
 // ErrorStack returns a string representation of the annotated error. If the
@@ -153,14 +251,15 @@ var (
 //
 // If the error is an annotated error, a multi-line string is returned where
 // each line represents one entry in the annotation stack. The full filename
-// from the call stack is used in the output.
+// from the call stack is used in the output, followed by the function name
+// when it is known.
 //
 //	first error
-//	github.com/juju/errors/annotation_test.go:193:
-//	github.com/juju/errors/annotation_test.go:194: annotation
-//	github.com/juju/errors/annotation_test.go:195:
-//	github.com/juju/errors/annotation_test.go:196: more context
-//	github.com/juju/errors/annotation_test.go:197:
+//	github.com/juju/errors/annotation_test.go:193 (errors.(*Suite).TestStack):
+//	github.com/juju/errors/annotation_test.go:194 (errors.(*Suite).TestStack): annotation
+//	github.com/juju/errors/annotation_test.go:195 (errors.(*Suite).TestStack):
+//	github.com/juju/errors/annotation_test.go:196 (errors.(*Suite).TestStack): more context
+//	github.com/juju/errors/annotation_test.go:197 (errors.(*Suite).TestStack):
 func ErrorStack(err error) string {
 	return strings.Join(errorStack(err), "\n")
 }
@@ -174,12 +273,19 @@ func errorStack(err error) []string {
 	var lines []string
 	for {
 		var buff []byte
+		var fields map[string]interface{}
+		if err, ok := err.(fielder); ok {
+			fields = err.Fields()
+		}
 		if err, ok := err.(locationer); ok {
 			file, line := err.Location()
 			// Strip off the leading GOPATH/src path elements.
 			file = trimSourcePath(file)
 			if file != "" {
 				buff = append(buff, fmt.Sprintf("%s:%d", file, line)...)
+				if ferr, ok := err.(functionLocationer); ok && ferr.Function() != "" {
+					buff = append(buff, fmt.Sprintf(" (%s)", ferr.Function())...)
+				}
 				buff = append(buff, ": "...)
 			}
 		}
@@ -199,10 +305,30 @@ func errorStack(err error) []string {
 				}
 				buff = append(buff, cause.Error()...)
 			}
+		} else if merr, ok := err.(multiUnwrapper); ok {
+			errs := merr.Unwrap()
+			buff = append(buff, fmt.Sprintf("%d errors occurred:", len(errs))...)
+			for _, child := range errs {
+				for i, line := range errorStack(child) {
+					if i == 0 {
+						buff = append(buff, "\n\t* "...)
+					} else {
+						buff = append(buff, "\n\t  "...)
+					}
+					buff = append(buff, line...)
+				}
+			}
+			err = nil
 		} else {
 			buff = append(buff, err.Error()...)
 			err = nil
 		}
+		if len(fields) > 0 {
+			if len(buff) > 0 && buff[len(buff)-1] != ' ' {
+				buff = append(buff, ' ')
+			}
+			buff = append(buff, formatFields(fields)...)
+		}
 		lines = append(lines, string(buff))
 		if err == nil {
 			break
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
 )
 
 type ReturnData struct {
@@ -18,9 +19,18 @@ const (
 )
 
 // ResponseErr response error, if err is not code error type, default return http.StatusInternalServerError
+// The error is recorded by the metrics recorder, see SetMetricsRecorder, reported
+// if it is a server fault, see SetDispatcher, and recorded on the span of the
+// request by GinTracing.
 func ResponseErr(g *gin.Context, err error) {
+	recordError(g.FullPath(), err, codes.Unknown)
+	reportError(g.FullPath(), err, codes.Unknown)
+	if err != nil {
+		g.Set(responseErrorKey, err)
+	}
 	if !responseByErr(g, err) {
-		Response(g, http.StatusInternalServerError, OkBizCode, nil, err.Error())
+		// The errors which aren't a CodeError keep the biz code 0.
+		response(g, DefaultCodeTable.HTTPStatus(codes.Unknown), OkBizCode, nil, responseMessage(err), err)
 	}
 }
 
@@ -29,20 +39,49 @@ func responseByErr(g *gin.Context, err error) bool {
 		Response(g, http.StatusOK, OkBizCode, nil, "")
 		return true
 	}
-	message := err.Error()
-	err = Cause(err)
-	if inner, ok := err.(*CodeError); ok {
+	message := responseMessage(err)
+	if inner, ok := AsCodeError(err); ok {
 		errCode := inner.HTTPCode
 		// if custom biz code, should be used
 		if inner.BizCode != OkBizCode {
 			errCode = inner.BizCode
 		}
-		Response(g, inner.HTTPCode, errCode, nil, message)
+		response(g, inner.HTTPCode, errCode, nil, message, err)
 		return true
 	}
 	return false
 }
 
+// GinRecovery returns a gin middleware which recovers from panics and responds
+// with an Internal CodeError carrying the panic value and stack through
+// ResponseErr, so that clients still get the {code, message, data} envelope.
+func GinRecovery() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		defer func() {
+			if r := recover(); r != nil {
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+				ResponseErr(g, newPanicError(r))
+				g.Abort()
+			}
+		}()
+		g.Next()
+	}
+}
+
+// GinTracing returns a gin middleware which records the error responded by
+// ResponseErr on the span of the request, see RecordSpanError. It must be
+// used after the middleware starting the span, e.g. otelgin.
+func GinTracing() gin.HandlerFunc {
+	return func(g *gin.Context) {
+		g.Next()
+		if err, ok := g.Get(responseErrorKey); ok {
+			RecordSpanError(g.Request.Context(), err.(error))
+		}
+	}
+}
+
 // ResponseOk response ok
 func ResponseOk(g *gin.Context, data interface{}, msg ...string) {
 	var s = ""
@@ -54,31 +93,46 @@ func ResponseOk(g *gin.Context, data interface{}, msg ...string) {
 
 // Response response json, if the above api doesn't satisfy your demands, should be used
 func Response(g *gin.Context, httpCode, errCode uint32, data interface{}, message string) {
+	response(g, httpCode, errCode, data, message, nil)
+}
+
+// response writes the envelope, the request and trace IDs attached to err
+// by WithContext are echoed, as is the debug detail of err in debug mode. The
+// Retry-After header is set for the errors retryable after a delay. The
+// message is the translation of the biz code, unless err has an explicit
+// public message.
+func response(g *gin.Context, httpCode, errCode uint32, data interface{}, message string, err error) {
 	translatedMsg := getTranslateMsg(g, errCode)
-	if translatedMsg != "" {
+	if translatedMsg != "" && !hasPublicMessage(err) {
 		message = translatedMsg
 	}
-	g.JSON(int(httpCode), gin.H{
+	body := gin.H{
 		"code":    errCode,
 		"message": message,
 		"data":    data,
-	})
+	}
+	if seconds := retryAfterSeconds(err); seconds != "" {
+		g.Header("Retry-After", seconds)
+	}
+	if id := RequestID(err); id != "" {
+		body[FieldRequestID] = id
+	}
+	if id := TraceID(err); id != "" {
+		body[FieldTraceID] = id
+	}
+	if debug := debugInfo(err); debug != nil {
+		body["debug"] = debug
+	}
+	g.JSON(int(httpCode), body)
 }
 
 func getTranslateMsg(g *gin.Context, bizCode uint32) string {
-	bizCodeStr := strconv.FormatInt(int64(bizCode), 10)
-	translated := TranslateWithConvertLan(g.GetHeader("LANGUAGE-TYPE"), bizCodeStr)
-	if translated == bizCodeStr {
-		return ""
-	}
-	return translated
+	return getTranslateMsgByLang(g.GetHeader("LANGUAGE-TYPE"), bizCode)
 }
 
+// getTranslateMsgByLang returns the translation of bizCode, or "" if there is
+// none, in which case the message of the error is kept.
 func getTranslateMsgByLang(lang string, bizCode uint32) string {
-	bizCodeStr := strconv.FormatInt(int64(bizCode), 10)
-	translated := TranslateWithConvertLan(lang, bizCodeStr)
-	if translated == bizCodeStr {
-		return ""
-	}
+	translated, _ := lookupTranslation(ConvertLang(lang), strconv.FormatInt(int64(bizCode), 10))
 	return translated
 }
@@ -0,0 +1,53 @@
+package errors
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc/codes"
+)
+
+func TestResponseErrPublicMessage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	respond := func(err error) ReturnData {
+		w := httptest.NewRecorder()
+		g, _ := gin.CreateTestContext(w)
+		g.Request = httptest.NewRequest("GET", "/", nil)
+		ResponseErr(g, err)
+		var body ReturnData
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatal(err)
+		}
+		return body
+	}
+
+	const bizCode = 1200009901 // no translation registered
+	leaky := NewCodeErrorf(codes.Internal, 500, bizCode, "dial db.internal:3306")
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{leaky, "Internal Server Error"},
+		{Errorf("dial db.internal:3306"), "Internal Server Error"},
+		{NewCodeErrorf(codes.InvalidArgument, 400, bizCode, "name is required"), "name is required"},
+		{&CodeError{Err: NewErr("dial db.internal:3306"), Code: codes.Internal, HTTPCode: 500, BizCode: bizCode, PublicMessage: "try later"}, "try later"},
+		{NotValidf("name"), "parameter error"},
+		{New(WithGRPCCode(codes.InvalidArgument), WithPublicMessage("name is required")), "name is required"},
+	}
+	for _, tt := range tests {
+		if got := respond(tt.err).Message; got != tt.want {
+			t.Errorf("message of %q = %q, want %q", tt.err, got, tt.want)
+		}
+	}
+	if got := ToGRPCStatus(leaky).Message(); got != "Internal Server Error" {
+		t.Errorf("grpc status message = %q", got)
+	}
+
+	previous := SetDebugMode(true)
+	defer SetDebugMode(previous)
+	if got := respond(leaky).Message; got != "dial db.internal:3306" {
+		t.Errorf("debug message = %q", got)
+	}
+}
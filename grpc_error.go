@@ -1,59 +1,61 @@
 package errors
 
 import (
-	"net/http"
+	"strconv"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
-var grpcCodeToHttpCode = map[codes.Code]uint32{
-	codes.OK:                 http.StatusOK,
-	codes.Canceled:           http.StatusRequestTimeout,
-	codes.Unknown:            http.StatusInternalServerError,
-	codes.InvalidArgument:    http.StatusBadRequest,
-	codes.DeadlineExceeded:   http.StatusRequestTimeout,
-	codes.NotFound:           http.StatusNotFound,
-	codes.AlreadyExists:      http.StatusConflict,
-	codes.PermissionDenied:   http.StatusForbidden,
-	codes.ResourceExhausted:  http.StatusGone,
-	codes.FailedPrecondition: http.StatusPreconditionFailed,
-	codes.Aborted:            http.StatusForbidden,
-	codes.OutOfRange:         http.StatusPreconditionFailed,
-	codes.Unimplemented:      http.StatusNotImplemented,
-	codes.Internal:           http.StatusInternalServerError,
-	codes.Unavailable:        http.StatusServiceUnavailable,
-	codes.DataLoss:           http.StatusGone,
-	codes.Unauthenticated:    http.StatusUnauthorized,
-}
-
-// ToGRPCStatus to grpc status error
+// ToGRPCStatus to grpc status error. The status message is the public
+// message of the error unless the debug mode is enabled, see SetDebugMode.
 func ToGRPCStatus(err error) *status.Status {
-	err = Cause(err)
 	if err == nil {
 		return nil
 	}
-	inner, ok := err.(*CodeError)
+	inner, ok := AsCodeError(err)
+	info := requestInfo(err)
 	if ok {
-		if inner.Code == codes.OK && inner.BizCode != 0 {
-			inner.Code = codes.Unknown
+		code := inner.Code
+		if code == codes.OK && inner.BizCode != 0 {
+			code = codes.Unknown
+		}
+		message := inner.GetPublicMessage()
+		if IsDebugMode() {
+			message = err.Error()
+		}
+		// The biz code is sent even if it is 0, it marks the status as built
+		// from a CodeError, see GRPCErrToError.
+		st, _ := status.New(code, message).WithDetails(&BizErrorCode{Code: inner.BizCode})
+		if info != nil {
+			st, _ = st.WithDetails(info)
+		}
+		if info := traceInfo(err, inner.BizCode); info != nil {
+			st, _ = st.WithDetails(info)
 		}
-		st := status.New(inner.Code, err.Error())
-		if inner.BizCode != 0 {
-			st, _ = st.WithDetails(&BizErrorCode{Code: inner.BizCode})
+		if inner.Retryable {
+			st, _ = st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(inner.RetryAfter)})
 		}
 		return st
 	}
-	st, _ := status.FromError(err)
-	return st
+	if st, ok := status.FromError(Cause(err)); ok {
+		return st
+	}
+	return status.New(codes.Unknown, responseMessage(err))
 }
 
-// ToGRPCReturnError generate grpc api return error
+// ToGRPCReturnError generate grpc api return error. The error is reported if
+// it is a server fault, see SetDispatcher. It isn't recorded by the metrics
+// recorder: install UnaryMetricsInterceptor and StreamMetricsInterceptor to
+// count the errors by method.
 func ToGRPCReturnError(err error) error {
 	st := ToGRPCStatus(err)
 	if st == nil {
 		return nil
 	}
+	reportError("", err, st.Code())
 	return st.Err()
 }
 
@@ -63,13 +65,55 @@ func GRPCErrToError(err error) error {
 		return nil
 	}
 	st, _ := status.FromError(err)
-	httpCode := grpcCodeToHttpCode[st.Code()]
-	codeErr := &CodeError{Err: wrap(nil, err.Error(), ""), Code: st.Code(), HTTPCode: httpCode}
+	m := DefaultCodeTable.Lookup(st.Code())
+	codeErr := &CodeError{Err: wrap(nil, err.Error(), ""), Code: st.Code(), HTTPCode: m.HTTPCode, BizCode: m.BizCode}
+	var fromCodeError, hasRetryInfo bool
 	details := st.Details()
 	for _, detail := range details {
-		if bizErrorCode, ok := detail.(*BizErrorCode); ok {
-			codeErr.BizCode = bizErrorCode.Code
+		switch detail := detail.(type) {
+		case *BizErrorCode:
+			codeErr.BizCode = detail.Code
+			fromCodeError = true
+		case *errdetails.RetryInfo:
+			hasRetryInfo = true
+			codeErr.RetryAfter = detail.GetRetryDelay().AsDuration()
+		case *errdetails.RequestInfo:
+			codeErr.setField(FieldRequestID, detail.RequestId)
+		case *errdetails.ErrorInfo:
+			if detail.Domain == errorInfoDomain {
+				codeErr.setField(FieldTraceID, detail.Metadata[FieldTraceID])
+			}
 		}
 	}
+	// The statuses built by ToGRPCStatus carry a BizErrorCode, and a RetryInfo
+	// when retryable, the other ones are retryable depending on their code.
+	codeErr.Retryable = hasRetryInfo || !fromCodeError && retryableByDefault(st.Code())
 	return codeErr
 }
+
+// errorInfoDomain is the domain of the ErrorInfo details of ToGRPCStatus.
+const errorInfoDomain = "github.com/zhwei820/errors"
+
+// requestInfo returns the request ID attached to err by WithContext as
+// status detail, or nil.
+func requestInfo(err error) *errdetails.RequestInfo {
+	if id := RequestID(err); id != "" {
+		return &errdetails.RequestInfo{RequestId: id}
+	}
+	return nil
+}
+
+// traceInfo returns the trace ID attached to err by WithContext as status
+// detail: an ErrorInfo whose reason is the biz code, with the trace ID in
+// its metadata. Nil is returned if err carries no trace ID.
+func traceInfo(err error, bizCode uint32) *errdetails.ErrorInfo {
+	id := TraceID(err)
+	if id == "" {
+		return nil
+	}
+	return &errdetails.ErrorInfo{
+		Reason:   strconv.FormatUint(uint64(bizCode), 10),
+		Domain:   errorInfoDomain,
+		Metadata: map[string]string{FieldTraceID: id},
+	}
+}
@@ -0,0 +1,84 @@
+package errors
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryRecoveryInterceptor returns a grpc unary server interceptor which
+// recovers from panics and returns an Internal CodeError carrying the panic
+// value and stack through ToGRPCReturnError.
+func UnaryRecoveryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = ToGRPCReturnError(newPanicError(r))
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// StreamRecoveryInterceptor returns a grpc stream server interceptor which
+// recovers from panics and returns an Internal CodeError carrying the panic
+// value and stack through ToGRPCReturnError.
+func StreamRecoveryInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = ToGRPCReturnError(newPanicError(r))
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// UnaryTracingInterceptor returns a grpc unary server interceptor which
+// records the error returned by the handler on the span of the call, see
+// RecordSpanError. The status errors returned by ToGRPCReturnError are
+// converted back with FromError, so their codes are recorded. It must be
+// chained after the interceptor starting the span, e.g. otelgrpc.
+func UnaryTracingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		RecordSpanError(ctx, FromError(err))
+		return resp, err
+	}
+}
+
+// StreamTracingInterceptor returns a grpc stream server interceptor which
+// records the error returned by the handler on the span of the stream, see
+// UnaryTracingInterceptor.
+func StreamTracingInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		RecordSpanError(ss.Context(), FromError(err))
+		return err
+	}
+}
+
+// UnaryMetricsInterceptor returns a grpc unary server interceptor which
+// records the error returned by the handler with the metrics recorder, see
+// SetMetricsRecorder, with the full method as endpoint. The status errors
+// returned by ToGRPCReturnError are converted back with FromError, so their
+// codes are recorded.
+func UnaryMetricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		resp, err := handler(ctx, req)
+		recordError(info.FullMethod, FromError(err), status.Code(err))
+		return resp, err
+	}
+}
+
+// StreamMetricsInterceptor returns a grpc stream server interceptor which
+// records the error returned by the handler with the metrics recorder, see
+// UnaryMetricsInterceptor.
+func StreamMetricsInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := handler(srv, ss)
+		recordError(info.FullMethod, FromError(err), status.Code(err))
+		return err
+	}
+}
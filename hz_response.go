@@ -1,21 +1,36 @@
 package errors
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/cloudwego/hertz/pkg/app"
+	"google.golang.org/grpc/codes"
 )
 
 type JSONResult struct {
 	Code    uint32      `json:"code"` // common code please see https://gitlab.matrixport.com/loan/document/-/blob/master/error/error_code.md
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
+
+	RequestID string     `json:"request_id,omitempty"` // attached to the error by WithContext
+	TraceID   string     `json:"trace_id,omitempty"`   // attached to the error by WithContext
+	Debug     *DebugInfo `json:"debug,omitempty"`      // only set in debug mode, see SetDebugMode
 }
 
 // ResponseErr response error, if err is not code error type, default return http.StatusInternalServerError
+// The error is recorded by the metrics recorder, see SetMetricsRecorder, reported
+// if it is a server fault, see SetDispatcher, and recorded on the span of the
+// request by HzTracing.
 func HzResponseErr(g *app.RequestContext, err error) {
+	recordError(g.FullPath(), err, codes.Unknown)
+	reportError(g.FullPath(), err, codes.Unknown)
+	if err != nil {
+		g.Set(responseErrorKey, err)
+	}
 	if !hzResponseByErr(g, err) {
-		HzResponse(g, http.StatusInternalServerError, OkBizCode, nil, err.Error())
+		// The errors which aren't a CodeError keep the biz code 0.
+		hzResponse(g, DefaultCodeTable.HTTPStatus(codes.Unknown), OkBizCode, nil, responseMessage(err), err)
 	}
 }
 
@@ -24,20 +39,50 @@ func hzResponseByErr(g *app.RequestContext, err error) bool {
 		HzResponse(g, http.StatusOK, OkBizCode, nil, "")
 		return true
 	}
-	message := err.Error()
-	err = Cause(err)
-	if inner, ok := err.(*CodeError); ok {
+	message := responseMessage(err)
+	if inner, ok := AsCodeError(err); ok {
 		errCode := inner.HTTPCode
 		// if custom biz code, should be used
 		if inner.BizCode != OkBizCode {
 			errCode = inner.BizCode
 		}
-		HzResponse(g, inner.HTTPCode, errCode, nil, message)
+		hzResponse(g, inner.HTTPCode, errCode, nil, message, err)
 		return true
 	}
 	return false
 }
 
+// HzRecovery returns a hertz middleware which recovers from panics and
+// responds with an Internal CodeError carrying the panic value and stack
+// through HzResponseErr, so that clients still get the {code, message, data}
+// envelope.
+func HzRecovery() app.HandlerFunc {
+	return func(c context.Context, g *app.RequestContext) {
+		defer func() {
+			if r := recover(); r != nil {
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+				HzResponseErr(g, newPanicError(r))
+				g.Abort()
+			}
+		}()
+		g.Next(c)
+	}
+}
+
+// HzTracing returns a hertz middleware which records the error responded by
+// HzResponseErr on the span of the request, see RecordSpanError. It must be
+// used after the middleware starting the span.
+func HzTracing() app.HandlerFunc {
+	return func(c context.Context, g *app.RequestContext) {
+		g.Next(c)
+		if err, ok := g.Get(responseErrorKey); ok {
+			RecordSpanError(c, err.(error))
+		}
+	}
+}
+
 // ResponseOk response ok
 func HzResponseOk(g *app.RequestContext, data interface{}, msg ...string) {
 	var s = ""
@@ -49,13 +94,28 @@ func HzResponseOk(g *app.RequestContext, data interface{}, msg ...string) {
 
 // Response response json, if the above api doesn't satisfy your demands, should be used
 func HzResponse(g *app.RequestContext, httpCode, errCode uint32, data interface{}, message string) {
+	hzResponse(g, httpCode, errCode, data, message, nil)
+}
+
+// hzResponse writes the envelope, the request and trace IDs attached to err
+// by WithContext are echoed, as is the debug detail of err in debug mode. The
+// Retry-After header is set for the errors retryable after a delay. The
+// message is the translation of the biz code, unless err has an explicit
+// public message.
+func hzResponse(g *app.RequestContext, httpCode, errCode uint32, data interface{}, message string, err error) {
 	translatedMsg := getTranslateMsgByLang(string(g.GetHeader("LANGUAGE-TYPE")), errCode)
-	if translatedMsg != "" {
+	if translatedMsg != "" && !hasPublicMessage(err) {
 		message = translatedMsg
 	}
+	if seconds := retryAfterSeconds(err); seconds != "" {
+		g.Header("Retry-After", seconds)
+	}
 	g.JSON(int(httpCode), JSONResult{
-		Code:    errCode,
-		Message: message,
-		Data:    data,
+		Code:      errCode,
+		Message:   message,
+		Data:      data,
+		RequestID: RequestID(err),
+		TraceID:   TraceID(err),
+		Debug:     debugInfo(err),
 	})
 }
@@ -0,0 +1,185 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// jsonError is the JSON representation of Err and CodeError:
+//
+//	{
+//	    "message":        "load order: order 42 Not Found",
+//	    "stack":          [{"file": "svc/order.go", "line": 42, "function": "svc.(*Order).Get"},
+//	                       {"file": "svc/handler.go", "line": 17, "function": "svc.Handle", "message": "load order"}],
+//	    "cause":          "record not found",
+//	    "grpc_code":      "NotFound",
+//	    "http_code":      404,
+//	    "biz_code":       12000005,
+//	    "public_message": "order not found",
+//	    "fields":         {"order_id": 42},
+//	    "fingerprint":    "5c0ce8b1e2e4b0f6"
+//	}
+//
+// The stack lists the recorded locations from the originating error to the
+// most recent annotation, like ErrorStack. The cause is the message of the
+// underlying error, if any. The codes and the explicit public message are
+// only set for a CodeError, the grpc code is the name returned by
+// codes.Code.String. A retryable CodeError also has "retryable": true and its
+// "retry_after" delay formatted by time.Duration.String, e.g. "1.5s". The
+// fingerprint is the one returned by Fingerprint with the default options.
+type jsonError struct {
+	Message       string                 `json:"message"`
+	Stack         []jsonLocation         `json:"stack,omitempty"`
+	Cause         string                 `json:"cause,omitempty"`
+	GRPCCode      string                 `json:"grpc_code,omitempty"`
+	HTTPCode      uint32                 `json:"http_code,omitempty"`
+	BizCode       uint32                 `json:"biz_code,omitempty"`
+	PublicMessage string                 `json:"public_message,omitempty"`
+	Retryable     bool                   `json:"retryable,omitempty"`
+	RetryAfter    string                 `json:"retry_after,omitempty"`
+	Fields        map[string]interface{} `json:"fields,omitempty"`
+	Fingerprint   string                 `json:"fingerprint,omitempty"`
+}
+
+type jsonLocation struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+func newJSONError(err error) jsonError {
+	j := jsonError{
+		Message:     err.Error(),
+		Fields:      Fields(err),
+		Fingerprint: Fingerprint(err),
+	}
+	// Walk the error stack, the most recent location first.
+	root := err
+	for e := err; e != nil; {
+		root = e
+		if l, ok := e.(locationer); ok {
+			file, line := l.Location()
+			if file != "" {
+				loc := jsonLocation{File: trimSourcePath(file), Line: line}
+				if f, ok := e.(functionLocationer); ok {
+					loc.Function = f.Function()
+				}
+				if w, ok := e.(wrapper); ok {
+					loc.Message = w.Message()
+				}
+				j.Stack = append(j.Stack, loc)
+			}
+		}
+		w, ok := e.(wrapper)
+		if !ok {
+			break
+		}
+		e = w.Underlying()
+	}
+	for i, k := 0, len(j.Stack)-1; i < k; i, k = i+1, k-1 {
+		j.Stack[i], j.Stack[k] = j.Stack[k], j.Stack[i]
+	}
+	cause := Cause(err)
+	if sameError(cause, err) {
+		cause = root
+	}
+	if !sameError(cause, err) {
+		j.Cause = cause.Error()
+	}
+	return j
+}
+
+// restore sets e from the decoded j. The location is set to the most recent
+// recorded one.
+func (e *Err) restore(j jsonError) {
+	*e = Err{
+		message: j.Message,
+		fields:  j.Fields,
+	}
+	if n := len(j.Stack); n > 0 {
+		e.file = j.Stack[n-1].File
+		e.line = j.Stack[n-1].Line
+		e.function = j.Stack[n-1].Function
+	}
+}
+
+// MarshalJSON implements json.Marshaler, see jsonError for the schema.
+func (e *Err) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newJSONError(e))
+}
+
+// UnmarshalJSON implements json.Unmarshaler. The error stack is not
+// restored: the message holds the complete error message and the location is
+// the most recent one.
+func (e *Err) UnmarshalJSON(data []byte) error {
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	e.restore(j)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler, see jsonError for the schema.
+func (c *CodeError) MarshalJSON() ([]byte, error) {
+	j := newJSONError(c)
+	j.GRPCCode = c.Code.String()
+	j.HTTPCode = c.HTTPCode
+	j.BizCode = c.BizCode
+	j.PublicMessage = c.PublicMessage
+	j.Retryable = c.Retryable
+	if c.RetryAfter > 0 {
+		j.RetryAfter = c.RetryAfter.String()
+	}
+	return json.Marshal(j)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Like GRPCErrToError the
+// resulting error carries the complete error message and codes of the
+// original one, so the IsXxx predicates keep working on the decoded error.
+func (c *CodeError) UnmarshalJSON(data []byte) error {
+	var j jsonError
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	code, err := parseGRPCCode(j.GRPCCode)
+	if err != nil {
+		return err
+	}
+	var retryAfter time.Duration
+	if j.RetryAfter != "" {
+		if retryAfter, err = time.ParseDuration(j.RetryAfter); err != nil {
+			return err
+		}
+	}
+	c.Err.restore(j)
+	c.Code = code
+	c.HTTPCode = j.HTTPCode
+	c.BizCode = j.BizCode
+	c.PublicMessage = j.PublicMessage
+	c.Retryable = j.Retryable
+	c.RetryAfter = retryAfter
+	return nil
+}
+
+// parseGRPCCode parses the name of a grpc code as returned by
+// codes.Code.String. An empty name is codes.OK.
+func parseGRPCCode(name string) (codes.Code, error) {
+	if name == "" {
+		return codes.OK, nil
+	}
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if c.String() == name {
+			return c, nil
+		}
+	}
+	var c codes.Code
+	if _, err := fmt.Sscanf(name, "Code(%d)", &c); err == nil {
+		return c, nil
+	}
+	return codes.Unknown, fmt.Errorf("invalid grpc code %q", name)
+}
@@ -0,0 +1,39 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"testing"
+)
+
+func TestCodeErrorJSONRoundTrip(t *testing.T) {
+	err := WithFields(NewNotFound(io.EOF, "order 42"), "order_id", 42)
+	err = Annotate(err, "load order")
+
+	data, jerr := json.Marshal(err)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	var j jsonError
+	if jerr := json.Unmarshal(data, &j); jerr != nil {
+		t.Fatal(jerr)
+	}
+	if j.Cause != "order 42: EOF" || len(j.Stack) != 3 || j.Stack[2].Message != "load order" {
+		t.Fatalf("unexpected JSON %s", data)
+	}
+
+	inner, _ := AsCodeError(err)
+	if data, jerr = json.Marshal(inner); jerr != nil {
+		t.Fatal(jerr)
+	}
+	decoded := &CodeError{}
+	if jerr := json.Unmarshal(data, decoded); jerr != nil {
+		t.Fatal(jerr)
+	}
+	if !IsNotFound(decoded) || decoded.HTTPCode != 404 || decoded.BizCode != ErrCodeNotFound.Int() {
+		t.Fatalf("unexpected decoded error %#v", decoded)
+	}
+	if decoded.Error() != inner.Error() {
+		t.Fatalf("message = %q, want %q", decoded.Error(), inner.Error())
+	}
+}
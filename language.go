@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"strconv"
 	"strings"
 )
 
@@ -48,15 +49,27 @@ func RegisterI18n(messages []TransInfo) {
 }
 
 func Translate(langSpec LangType, key string) string {
-	res, ok := messageMap[key]
-	if !ok {
+	if msg, ok := lookupTranslation(langSpec, key); ok {
+		return msg
+	}
+	if _, ok := messageMap[key]; !ok {
 		return "msg not found: " + key
 	}
-	ret, ok := res[langSpec]
-	if !ok {
-		return "msg not found: " + key + " ," + langSpec.String()
+	return "msg not found: " + key + " ," + langSpec.String()
+}
+
+// lookupTranslation returns the message registered for key in langSpec, or
+// else the default message of the biz code key declared in the
+// DefaultRegistry.
+func lookupTranslation(langSpec LangType, key string) (string, bool) {
+	if ret, ok := messageMap[key][langSpec]; ok {
+		return ret.Msg, true
+	}
+	bizCode, err := strconv.ParseUint(key, 10, 32)
+	if err != nil {
+		return "", false
 	}
-	return ret.Msg
+	return DefaultRegistry.message(langSpec, uint32(bizCode))
 }
 
 func TranslateWithConvertLan(langRaw, key string) string {
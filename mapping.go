@@ -0,0 +1,176 @@
+package errors
+
+import (
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+// CodeMapping maps a grpc code to its http status and default biz code.
+type CodeMapping struct {
+	GRPCCode codes.Code
+	HTTPCode uint32
+	BizCode  uint32
+}
+
+// defaultCodeMappings is the canonical mapping. When several grpc codes share
+// an http status, the first one is the grpc code of the status, see
+// CodeTable.FromHTTPStatus.
+var defaultCodeMappings = []CodeMapping{
+	{codes.OK, http.StatusOK, OkBizCode},
+	{codes.Canceled, StatusClientClosedRequest, ErrCodeCanceled.Int()},
+	{codes.InvalidArgument, http.StatusBadRequest, ErrCodeBadRequest.Int()},
+	{codes.DeadlineExceeded, http.StatusGatewayTimeout, ErrCodeDeadlineExceeded.Int()},
+	{codes.NotFound, http.StatusNotFound, ErrCodeNotFound.Int()},
+	{codes.AlreadyExists, http.StatusConflict, ErrCodeConflict.Int()},
+	{codes.PermissionDenied, http.StatusForbidden, ErrCodeForbidden.Int()},
+	{codes.ResourceExhausted, http.StatusTooManyRequests, ErrCodeResourceExhausted.Int()},
+	{codes.FailedPrecondition, http.StatusPreconditionFailed, ErrCodePreconditionFailed.Int()},
+	{codes.Aborted, http.StatusConflict, ErrCodeAborted.Int()},
+	{codes.OutOfRange, http.StatusBadRequest, ErrCodeOutOfRange.Int()},
+	{codes.Unimplemented, http.StatusNotImplemented, ErrCodeNotImplemented.Int()},
+	{codes.Internal, http.StatusInternalServerError, ErrCodeInternalServerError.Int()},
+	{codes.Unknown, http.StatusInternalServerError, ErrCodeUnknown.Int()},
+	{codes.Unavailable, http.StatusServiceUnavailable, ErrCodeServiceUnavailable.Int()},
+	{codes.DataLoss, http.StatusInternalServerError, ErrCodeDataLoss.Int()},
+	{codes.Unauthenticated, http.StatusUnauthorized, ErrCodeUnauthorized.Int()},
+}
+
+// CodeTable is a bidirectional mapping between the grpc codes, the http
+// statuses and the default biz codes. The DefaultCodeTable is used by the
+// constructors, GRPCErrToError and the responders.
+type CodeTable struct {
+	mu       sync.RWMutex
+	mappings []CodeMapping         // in the order of precedence of FromHTTPStatus
+	statuses map[uint32]codes.Code // the overrides of FromHTTPStatus
+}
+
+// NewCodeTable returns a CodeTable holding the canonical mapping:
+//
+//	OK                  200  0
+//	Canceled            499  ErrCodeCanceled
+//	InvalidArgument     400  ErrCodeBadRequest
+//	DeadlineExceeded    504  ErrCodeDeadlineExceeded
+//	NotFound            404  ErrCodeNotFound
+//	AlreadyExists       409  ErrCodeConflict
+//	PermissionDenied    403  ErrCodeForbidden
+//	ResourceExhausted   429  ErrCodeResourceExhausted
+//	FailedPrecondition  412  ErrCodePreconditionFailed
+//	Aborted             409  ErrCodeAborted
+//	OutOfRange          400  ErrCodeOutOfRange
+//	Unimplemented       501  ErrCodeNotImplemented
+//	Internal            500  ErrCodeInternalServerError
+//	Unknown             500  ErrCodeUnknown
+//	Unavailable         503  ErrCodeServiceUnavailable
+//	DataLoss            500  ErrCodeDataLoss
+//	Unauthenticated     401  ErrCodeUnauthorized
+func NewCodeTable() *CodeTable {
+	return &CodeTable{
+		mappings: append([]CodeMapping(nil), defaultCodeMappings...),
+		statuses: map[uint32]codes.Code{},
+	}
+}
+
+// Set overrides the mapping of m.GRPCCode and returns the previous one, if
+// any, e.g. to respond 403 to the Aborted errors of a service:
+//
+//	errors.DefaultCodeTable.Set(errors.CodeMapping{GRPCCode: codes.Aborted, HTTPCode: 403, BizCode: errors.ErrCodeAborted.Int()})
+func (t *CodeTable) Set(m CodeMapping) (CodeMapping, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, prev := range t.mappings {
+		if prev.GRPCCode == m.GRPCCode {
+			t.mappings[i] = m
+			return prev, true
+		}
+	}
+	t.mappings = append(t.mappings, m)
+	return CodeMapping{}, false
+}
+
+// Delete removes the mapping of code, which then maps to 500 without biz
+// code, see Lookup.
+func (t *CodeTable) Delete(code codes.Code) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i, m := range t.mappings {
+		if m.GRPCCode == code {
+			t.mappings = append(t.mappings[:i], t.mappings[i+1:]...)
+			return
+		}
+	}
+}
+
+// SetHTTPStatus overrides the grpc code returned by FromHTTPStatus for
+// status.
+func (t *CodeTable) SetHTTPStatus(status uint32, code codes.Code) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.statuses[status] = code
+}
+
+// Lookup returns the mapping of code. The codes out of the table map to 500
+// without biz code.
+func (t *CodeTable) Lookup(code codes.Code) CodeMapping {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.lookup(code)
+}
+
+func (t *CodeTable) lookup(code codes.Code) CodeMapping {
+	for _, m := range t.mappings {
+		if m.GRPCCode == code {
+			return m
+		}
+	}
+	return CodeMapping{GRPCCode: code, HTTPCode: http.StatusInternalServerError}
+}
+
+// HTTPStatus returns the http status of code.
+func (t *CodeTable) HTTPStatus(code codes.Code) uint32 {
+	return t.Lookup(code).HTTPCode
+}
+
+// FromHTTPStatus returns the mapping of the grpc code of the http status,
+// with status as http status. The statuses out of the table map to OK below
+// 400, FailedPrecondition for the other 4xx and Unknown otherwise.
+func (t *CodeTable) FromHTTPStatus(status uint32) CodeMapping {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	code, ok := t.statuses[status]
+	if !ok {
+		for _, m := range t.mappings {
+			if m.HTTPCode == status {
+				code, ok = m.GRPCCode, true
+				break
+			}
+		}
+	}
+	if !ok {
+		switch {
+		case status < http.StatusBadRequest:
+			code = codes.OK
+		case status < http.StatusInternalServerError:
+			code = codes.FailedPrecondition
+		default:
+			code = codes.Unknown
+		}
+	}
+	m := t.lookup(code)
+	m.HTTPCode = status
+	return m
+}
+
+// DefaultCodeTable is the mapping used by the constructors, GRPCErrToError
+// and the responders. It can be overridden per service with Set and
+// SetHTTPStatus, before the errors are created. The http statuses of the
+// preset biz codes and the message suffixes of the constructors, e.g. the
+// "Not Found" of NotFoundf, follow it.
+var DefaultCodeTable = NewCodeTable()
+
+// FromHTTPStatus returns the mapping of the http status in the
+// DefaultCodeTable, see CodeTable.FromHTTPStatus.
+func FromHTTPStatus(status uint32) CodeMapping {
+	return DefaultCodeTable.FromHTTPStatus(status)
+}
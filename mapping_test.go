@@ -0,0 +1,93 @@
+package errors
+
+import (
+	"net/http"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCodeTable(t *testing.T) {
+	for _, m := range defaultCodeMappings {
+		if m.GRPCCode == codes.OK {
+			continue
+		}
+		spec, ok := LookupCode(m.BizCode)
+		if !ok || spec.GRPCCode != m.GRPCCode || spec.HTTPCode != m.HTTPCode {
+			t.Errorf("mapping %v disagrees with the registry: %v", m, spec)
+		}
+	}
+
+	for _, spec := range presetCodes {
+		if spec.HTTPCode != DefaultCodeTable.HTTPStatus(spec.GRPCCode) {
+			t.Errorf("preset %d disagrees with the mapping of %s", spec.BizCode, spec.GRPCCode)
+		}
+	}
+
+	tests := []struct {
+		status uint32
+		code   codes.Code
+	}{
+		{http.StatusOK, codes.OK},
+		{http.StatusNoContent, codes.OK},
+		{http.StatusBadRequest, codes.InvalidArgument},
+		{http.StatusConflict, codes.AlreadyExists},
+		{http.StatusTooManyRequests, codes.ResourceExhausted},
+		{http.StatusInternalServerError, codes.Internal},
+		{http.StatusTeapot, codes.FailedPrecondition},
+		{http.StatusBadGateway, codes.Unknown},
+	}
+	for _, tt := range tests {
+		if m := FromHTTPStatus(tt.status); m.GRPCCode != tt.code || m.HTTPCode != tt.status {
+			t.Errorf("FromHTTPStatus(%d) = %v, want %s", tt.status, m, tt.code)
+		}
+	}
+
+	table := NewCodeTable()
+	prev, ok := table.Set(CodeMapping{GRPCCode: codes.Aborted, HTTPCode: http.StatusForbidden, BizCode: 1200009902})
+	if !ok || prev.HTTPCode != http.StatusConflict || prev.BizCode != ErrCodeAborted.Int() {
+		t.Errorf("previous mapping = %v, %v", prev, ok)
+	}
+	if m := table.Lookup(codes.Aborted); m.HTTPCode != http.StatusForbidden || m.BizCode != 1200009902 {
+		t.Errorf("overridden mapping = %v", m)
+	}
+	table.SetHTTPStatus(http.StatusForbidden, codes.Aborted)
+	if m := table.FromHTTPStatus(http.StatusForbidden); m.GRPCCode != codes.Aborted {
+		t.Errorf("FromHTTPStatus(403) = %v", m)
+	}
+	if m := DefaultCodeTable.Lookup(codes.Aborted); m.HTTPCode != http.StatusConflict {
+		t.Errorf("DefaultCodeTable changed: %v", m)
+	}
+
+	custom := codes.Code(100)
+	if _, ok := table.Set(CodeMapping{GRPCCode: custom, HTTPCode: http.StatusTeapot}); ok {
+		t.Error("a previous mapping is returned for a new code")
+	}
+	table.Delete(custom)
+	if m := table.Lookup(custom); m.HTTPCode != http.StatusInternalServerError || len(table.mappings) != len(defaultCodeMappings) {
+		t.Errorf("mapping of a deleted code = %v", m)
+	}
+}
+
+func TestCodeTableConstructors(t *testing.T) {
+	codeErr, _ := AsCodeError(Abortedf("version mismatch"))
+	if codeErr.HTTPCode != http.StatusConflict || codeErr.BizCode != ErrCodeAborted.Int() {
+		t.Errorf("Abortedf codes = %d %d", codeErr.HTTPCode, codeErr.BizCode)
+	}
+	codeErr, _ = AsCodeError(GRPCErrToError(status.Error(codes.ResourceExhausted, "quota")))
+	if codeErr.HTTPCode != http.StatusTooManyRequests || codeErr.BizCode != ErrCodeResourceExhausted.Int() {
+		t.Errorf("GRPCErrToError codes = %d %d", codeErr.HTTPCode, codeErr.BizCode)
+	}
+
+	prev, _ := DefaultCodeTable.Set(CodeMapping{GRPCCode: codes.NotFound, HTTPCode: http.StatusGone, BizCode: ErrCodeNotFound.Int()})
+	defer DefaultCodeTable.Set(prev)
+	codeErr, _ = AsCodeError(NotFoundf("order %d", 42))
+	if codeErr.HTTPCode != http.StatusGone || codeErr.Error() != "order 42 Gone" {
+		t.Errorf("NotFoundf = %q with http status %d with an overridden mapping", codeErr, codeErr.HTTPCode)
+	}
+	codeErr, _ = AsCodeError(NewBizCodeError(ErrCodeNotFound.Int()))
+	if codeErr.HTTPCode != http.StatusGone {
+		t.Errorf("NewBizCodeError http status = %d with an overridden mapping", codeErr.HTTPCode)
+	}
+}
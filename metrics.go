@@ -0,0 +1,146 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+// ErrorLabels identifies the errors counted by a MetricsRecorder.
+type ErrorLabels struct {
+	GRPCCode codes.Code
+	HTTPCode uint32
+	BizCode  uint32
+	Endpoint string // the route of gin and hertz, e.g. "/orders/:id", or the full grpc method
+}
+
+// MetricsRecorder records the errors returned through ResponseErr,
+// HzResponseErr and the grpc handlers. The grpc errors are only recorded by
+// UnaryMetricsInterceptor and StreamMetricsInterceptor, which the servers
+// must install: ToGRPCReturnError doesn't know the method. It is implemented
+// by MetricsRegistry and can be implemented on top of other metric backends,
+// see SetMetricsRecorder.
+type MetricsRecorder interface {
+	RecordError(labels ErrorLabels)
+}
+
+// DefaultMetrics is the registry recording the errors unless another
+// recorder is set with SetMetricsRecorder.
+var DefaultMetrics = NewMetricsRegistry()
+
+var metrics = struct {
+	sync.RWMutex
+	recorder MetricsRecorder
+}{recorder: DefaultMetrics}
+
+// SetMetricsRecorder sets the recorder of the errors returned through the
+// responders, a nil recorder disables the recording. The previous recorder is
+// returned.
+func SetMetricsRecorder(recorder MetricsRecorder) MetricsRecorder {
+	metrics.Lock()
+	defer metrics.Unlock()
+	prev := metrics.recorder
+	metrics.recorder = recorder
+	return prev
+}
+
+// recordError records err, returned on endpoint, see errorLabels.
+func recordError(endpoint string, err error, code codes.Code) {
+	metrics.RLock()
+	recorder := metrics.recorder
+	metrics.RUnlock()
+	if recorder == nil || err == nil {
+		return
+	}
+	recorder.RecordError(errorLabels(endpoint, err, code))
+}
+
+// errorLabels returns the labels of err, returned on endpoint. The codes of a
+// CodeError are used, the other errors have code and its http status.
+func errorLabels(endpoint string, err error, code codes.Code) ErrorLabels {
+	labels := ErrorLabels{GRPCCode: code, HTTPCode: DefaultCodeTable.HTTPStatus(code), Endpoint: endpoint}
+	if inner, ok := AsCodeError(err); ok {
+		labels.GRPCCode, labels.HTTPCode, labels.BizCode = inner.Code, inner.HTTPCode, inner.BizCode
+	}
+	return labels
+}
+
+// MetricsRegistry is an in-memory MetricsRecorder counting the errors by
+// labels. It is an http.Handler exposing the counters in the Prometheus text
+// format, e.g.
+//
+//	http.Handle("/metrics/errors", errors.DefaultMetrics)
+type MetricsRegistry struct {
+	mu     sync.Mutex
+	counts map[ErrorLabels]uint64
+}
+
+// NewMetricsRegistry returns an empty MetricsRegistry.
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{counts: map[ErrorLabels]uint64{}}
+}
+
+// RecordError implements MetricsRecorder.
+func (r *MetricsRegistry) RecordError(labels ErrorLabels) {
+	r.mu.Lock()
+	r.counts[labels]++
+	r.mu.Unlock()
+}
+
+// Count returns the number of errors recorded with labels.
+func (r *MetricsRegistry) Count(labels ErrorLabels) uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.counts[labels]
+}
+
+// Snapshot returns a copy of the counters.
+func (r *MetricsRegistry) Snapshot() map[ErrorLabels]uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := make(map[ErrorLabels]uint64, len(r.counts))
+	for labels, count := range r.counts {
+		counts[labels] = count
+	}
+	return counts
+}
+
+// Reset clears the counters.
+func (r *MetricsRegistry) Reset() {
+	r.mu.Lock()
+	r.counts = map[ErrorLabels]uint64{}
+	r.mu.Unlock()
+}
+
+// ServeHTTP writes the counters in the Prometheus text exposition format, as
+// the counter "code_errors_total" labelled by grpc_code, http_status,
+// biz_code and endpoint.
+func (r *MetricsRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(r.exposition()))
+}
+
+func (r *MetricsRegistry) exposition() string {
+	counts := r.Snapshot()
+	lines := make([]string, 0, len(counts))
+	for labels, count := range counts {
+		lines = append(lines, fmt.Sprintf("code_errors_total{grpc_code=%q,http_status=\"%d\",biz_code=\"%d\",endpoint=\"%s\"} %d\n",
+			labels.GRPCCode.String(), labels.HTTPCode, labels.BizCode, escapeLabelValue(labels.Endpoint), count))
+	}
+	sort.Strings(lines)
+	return "# HELP code_errors_total Number of errors returned, by grpc code, http status, biz code and endpoint.\n" +
+		"# TYPE code_errors_total counter\n" +
+		strings.Join(lines, "")
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeLabelValue escapes a label value as required by the Prometheus text
+// format.
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
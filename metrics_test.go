@@ -0,0 +1,59 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := NewMetricsRegistry()
+	defer SetMetricsRecorder(SetMetricsRecorder(registry))
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/orders/:id", func(g *gin.Context) {
+		ResponseErr(g, NotFoundf("order %s", g.Param("id")))
+	})
+	for _, id := range []string{"1", "2"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/"+id, nil))
+	}
+	const method = "/orders.Orders/Get"
+	interceptor := UnaryMetricsInterceptor()
+	for _, err := range []error{Errorf("boom"), nil} {
+		_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method}, func(context.Context, interface{}) (interface{}, error) {
+			return nil, ToGRPCReturnError(err)
+		})
+	}
+
+	notFound := ErrorLabels{GRPCCode: codes.NotFound, HTTPCode: http.StatusNotFound, BizCode: ErrCodeNotFound.Int(), Endpoint: "/orders/:id"}
+	if got := registry.Count(notFound); got != 2 {
+		t.Errorf("count of %+v = %d, want 2", notFound, got)
+	}
+	unknown := ErrorLabels{GRPCCode: codes.Unknown, HTTPCode: http.StatusInternalServerError, BizCode: ErrCodeUnknown.Int(), Endpoint: method}
+	if got := registry.Count(unknown); got != 1 {
+		t.Errorf("count of %+v = %d, want 1", unknown, got)
+	}
+	if n := len(registry.Snapshot()); n != 2 {
+		t.Errorf("%d series recorded, want 2", n)
+	}
+
+	w := httptest.NewRecorder()
+	registry.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	want := "# HELP code_errors_total Number of errors returned, by grpc code, http status, biz code and endpoint.\n" +
+		"# TYPE code_errors_total counter\n" +
+		`code_errors_total{grpc_code="NotFound",http_status="404",biz_code="12000005",endpoint="/orders/:id"} 2` + "\n" +
+		`code_errors_total{grpc_code="Unknown",http_status="500",biz_code="12000002",endpoint="/orders.Orders/Get"} 1` + "\n"
+	if got := w.Body.String(); got != want {
+		t.Errorf("exposition = %q, want %q", got, want)
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("unexpected content type %q", w.Header().Get("Content-Type"))
+	}
+}
@@ -0,0 +1,152 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	"google.golang.org/grpc/codes"
+)
+
+// MultiError is a list of errors returned as a single error, e.g. the
+// failures of a batch operation.
+type MultiError struct {
+	errs []error
+}
+
+// Join returns an error that wraps the given errors, nil errors are
+// discarded. Join returns nil if every value in errs is nil.
+func Join(errs ...error) error {
+	return Append(nil, errs...)
+}
+
+// Append appends errs to err. If err is already a *MultiError the errors are
+// added to a copy of its list, otherwise err becomes the first error of a new
+// *MultiError. Nil errors are discarded and nil is returned if there is no
+// error at all.
+//
+// For example:
+//
+//	var err error
+//	for _, item := range items {
+//	    err = errors.Append(err, process(item))
+//	}
+//	return err
+func Append(err error, errs ...error) error {
+	var all []error
+	if m, ok := err.(*MultiError); ok && m != nil {
+		all = append(all, m.errs...)
+	} else if err != nil {
+		all = append(all, err)
+	}
+	for _, e := range errs {
+		if e != nil {
+			all = append(all, e)
+		}
+	}
+	if len(all) == 0 {
+		return nil
+	}
+	return &MultiError{errs: all}
+}
+
+// Errors returns the wrapped errors.
+func (m *MultiError) Errors() []error {
+	return m.errs
+}
+
+// Unwrap returns the wrapped errors, it is used by errors.Is and errors.As.
+func (m *MultiError) Unwrap() []error {
+	return m.errs
+}
+
+// Error implements error.Error.
+func (m *MultiError) Error() string {
+	if len(m.errs) == 1 {
+		return m.errs[0].Error()
+	}
+	msgs := make([]string, len(m.errs))
+	for i, err := range m.errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors occurred: %s", len(m.errs), strings.Join(msgs, "; "))
+}
+
+// Format implements fmt.Formatter
+// When printing errors with %+v it prints the stack trace of every error.
+func (m *MultiError) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			fmt.Fprintf(s, "%s", ErrorStack(m))
+			return
+		}
+		fallthrough
+	case 's':
+		fmt.Fprintf(s, "%s", m.Error())
+	case 'q':
+		fmt.Fprintf(s, "%q", m.Error())
+	default:
+		fmt.Fprintf(s, "%%!%c(%T=%s)", verb, m, m.Error())
+	}
+}
+
+// Dominant returns the most severe *CodeError found in the wrapped errors
+// according to the severity order, see SetSeverityOrder. When several errors
+// are equally severe, the first one wins. Nil is returned if none of the
+// errors is a *CodeError.
+func (m *MultiError) Dominant() *CodeError {
+	return dominantCodeError(m.errs, 0)
+}
+
+var severityValue atomic.Value
+
+// defaultSeverityOrder lists the grpc codes from the most to the least
+// severe: server faults first, then the client errors.
+var defaultSeverityOrder = []codes.Code{
+	codes.DataLoss,
+	codes.Internal,
+	codes.Unknown,
+	codes.Unavailable,
+	codes.DeadlineExceeded,
+	codes.Unimplemented,
+	codes.ResourceExhausted,
+	codes.Aborted,
+	codes.Canceled,
+	codes.Unauthenticated,
+	codes.PermissionDenied,
+	codes.FailedPrecondition,
+	codes.OutOfRange,
+	codes.AlreadyExists,
+	codes.NotFound,
+	codes.InvalidArgument,
+	codes.OK,
+}
+
+// SetSeverityOrder sets the order, from the most to the least severe, used
+// to pick the dominant *CodeError of a *MultiError. Codes missing from the
+// order are less severe than all the listed ones. The previous order is
+// returned.
+func SetSeverityOrder(order ...codes.Code) []codes.Code {
+	previous := defaultSeverityOrder
+	if v := severityValue.Load(); v != nil {
+		previous = v.([]codes.Code)
+	}
+	severityValue.Store(append([]codes.Code(nil), order...))
+	return previous
+}
+
+// severity returns the rank of code in the severity order, lower is more
+// severe.
+func severity(code codes.Code) int {
+	order := defaultSeverityOrder
+	if v := severityValue.Load(); v != nil {
+		order = v.([]codes.Code)
+	}
+	for i, c := range order {
+		if c == code {
+			return i
+		}
+	}
+	return len(order)
+}
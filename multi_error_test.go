@@ -0,0 +1,36 @@
+package errors
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestMultiErrorDominant(t *testing.T) {
+	var err error
+	err = Append(err, NotValidf("name"))
+	err = Append(err, nil, Annotate(Internalf("db down"), "save"))
+	err = Append(err, NotFoundf("order"))
+
+	if got := len(err.(*MultiError).Errors()); got != 3 {
+		t.Fatalf("got %d errors, want 3", got)
+	}
+	if st := ToGRPCStatus(err); st.Code() != codes.Internal {
+		t.Fatalf("dominant code = %s, want Internal", st.Code())
+	}
+
+	previous := SetSeverityOrder(codes.NotFound)
+	defer SetSeverityOrder(previous...)
+	if st := ToGRPCStatus(err); st.Code() != codes.NotFound {
+		t.Fatalf("dominant code = %s, want NotFound", st.Code())
+	}
+
+	stack := ErrorStack(err)
+	if !strings.HasPrefix(stack, "3 errors occurred:\n\t* ") || !strings.Contains(stack, "\n\t  ") {
+		t.Fatalf("unexpected error stack:\n%s", stack)
+	}
+	if Join(nil, nil) != nil {
+		t.Fatal("Join of nil errors is not nil")
+	}
+}
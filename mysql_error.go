@@ -3,7 +3,6 @@ package errors
 import (
 	innerErr "errors"
 	"net/http"
-	"strconv"
 
 	"github.com/go-sql-driver/mysql"
 	"google.golang.org/grpc/codes"
@@ -35,31 +34,12 @@ func V2MysqlErr(err error) error {
 	if IsDuplicateError(err) {
 		return NewAlreadyExists(err, "")
 	}
-	return &CodeError{Err: wrap(err, " mysql error", ""), Code: codes.Internal, HTTPCode: http.StatusInternalServerError, BizCode: MysqlErrorBizCode}
+	return newCodeError(1, " mysql error", "", nil, WithGRPCCode(codes.Internal), WithBizCode(MysqlErrorBizCode), WithCause(err))
 }
 
-func init() {
-	strMysqlErrorBizCode := strconv.Itoa(int(MysqlErrorBizCode))
-	RegisterI18n([]TransInfo{
-		{
-			Lang: EnUs,
-			Key:  strMysqlErrorBizCode,
-			Msg:  "network error",
-		},
-		{
-			Lang: ZhCn,
-			Key:  strMysqlErrorBizCode,
-			Msg:  "网络错误",
-		},
-		{
-			Lang: ZhTW,
-			Key:  strMysqlErrorBizCode,
-			Msg:  "網絡錯誤",
-		},
-		{
-			Lang: RuRu,
-			Key:  strMysqlErrorBizCode,
-			Msg:  "network error",
-		},
-	})
-}
+var _ = MustRegister(CodeSpec{
+	BizCode:  MysqlErrorBizCode,
+	GRPCCode: codes.Internal,
+	HTTPCode: http.StatusInternalServerError,
+	Messages: map[LangType]string{EnUs: "network error", ZhCn: "网络错误", ZhTW: "網絡錯誤", RuRu: "network error"},
+})
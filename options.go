@@ -0,0 +1,179 @@
+package errors
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Option configures the CodeError built by New and Newf.
+type Option func(*codeErrorOptions)
+
+type codeErrorOptions struct {
+	code          *codes.Code
+	httpCode      uint32
+	bizCode       *uint32
+	previous      error
+	publicMessage string
+	fields        map[string]interface{}
+	retryable     *bool
+	retryAfter    time.Duration
+	callerSkip    int
+}
+
+// WithGRPCCode sets the grpc code, codes.Unknown by default unless the biz
+// code is declared in the DefaultRegistry. The http status and biz code
+// default to the ones of the grpc code in the DefaultCodeTable.
+func WithGRPCCode(code codes.Code) Option {
+	return func(o *codeErrorOptions) {
+		o.code = &code
+	}
+}
+
+// WithHTTPStatus sets the http status, by default the one of the grpc code,
+// see DefaultCodeTable.
+func WithHTTPStatus(httpCode uint32) Option {
+	return func(o *codeErrorOptions) {
+		o.httpCode = httpCode
+	}
+}
+
+// WithBizCode sets the biz code, by default the one of the grpc code, see
+// DefaultCodeTable. The grpc code and http status declared for it in the
+// DefaultRegistry are used unless they are set.
+func WithBizCode(bizCode uint32) Option {
+	return func(o *codeErrorOptions) {
+		o.bizCode = &bizCode
+	}
+}
+
+// WithCause sets the error wrapped by the CodeError, as the previous error
+// of Wrap: the CodeError stays the cause, so that its codes survive Annotate
+// and Trace, and errors.Is sees through it to err.
+func WithCause(err error) Option {
+	return func(o *codeErrorOptions) {
+		o.previous = err
+	}
+}
+
+// WithPublicMessage sets the message shown to clients, see
+// CodeError.GetPublicMessage.
+func WithPublicMessage(msg string) Option {
+	return func(o *codeErrorOptions) {
+		o.publicMessage = msg
+	}
+}
+
+// WithDetails attaches fields as alternating keys and values, see WithFields.
+func WithDetails(keyvals ...interface{}) Option {
+	return func(o *codeErrorOptions) {
+		for k, v := range makeFields(keyvals) {
+			if o.fields == nil {
+				o.fields = map[string]interface{}{}
+			}
+			o.fields[k] = v
+		}
+	}
+}
+
+// WithRetryable sets whether the error is safe to retry, by default the
+// Unavailable and Aborted errors are.
+func WithRetryable(retryable bool) Option {
+	return func(o *codeErrorOptions) {
+		o.retryable = &retryable
+	}
+}
+
+// WithRetryAfter sets the delay before a retry, and makes the error
+// retryable.
+func WithRetryAfter(delay time.Duration) Option {
+	return func(o *codeErrorOptions) {
+		retryable := true
+		o.retryable, o.retryAfter = &retryable, delay
+	}
+}
+
+// WithCallerSkip skips skip more frames when recording the location and the
+// stack of the error, e.g. 1 in a helper wrapping New, so that the error is
+// located at the caller of the helper.
+func WithCallerSkip(skip int) Option {
+	return func(o *codeErrorOptions) {
+		o.callerSkip += skip
+	}
+}
+
+// New returns a CodeError configured by opts, e.g.
+//
+//	return errors.New(errors.WithGRPCCode(codes.NotFound), errors.WithCause(err))
+func New(opts ...Option) error {
+	return newCodeError(1, "", "", nil, opts...)
+}
+
+// Newf returns a CodeError with a formatted message. The Option values in
+// args configure the error, the other ones are formatted, e.g.
+//
+//	return errors.Newf("order %d", id, errors.WithGRPCCode(codes.NotFound), errors.WithBizCode(1200020001))
+func Newf(format string, args ...interface{}) error {
+	var opts []Option
+	formatArgs := args[:0:0]
+	for _, arg := range args {
+		if opt, ok := arg.(Option); ok {
+			opts = append(opts, opt)
+		} else {
+			formatArgs = append(formatArgs, arg)
+		}
+	}
+	return newCodeError(1, format, "", formatArgs, opts...)
+}
+
+// newCodeError builds the CodeError of opts with the message format+suffix
+// formatted with args. The location and stack are recorded callDepth frames
+// above the caller of newCodeError.
+func newCodeError(callDepth int, format, suffix string, args []interface{}, opts ...Option) *CodeError {
+	var o codeErrorOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	code, httpCode := codes.Unknown, uint32(0)
+	if o.bizCode != nil && *o.bizCode != OkBizCode {
+		if spec, ok := LookupCode(*o.bizCode); ok {
+			code, httpCode = spec.GRPCCode, spec.HTTPCode
+		}
+	}
+	if o.code != nil {
+		code, httpCode = *o.code, 0
+	}
+	m := DefaultCodeTable.Lookup(code)
+	if o.httpCode != 0 {
+		httpCode = o.httpCode
+	}
+	if httpCode == 0 {
+		httpCode = m.HTTPCode
+	}
+	bizCode := m.BizCode
+	if o.bizCode != nil {
+		bizCode = *o.bizCode
+	}
+	retryable := retryableByDefault(code)
+	if o.retryable != nil {
+		retryable = *o.retryable
+	}
+	codeErr := &CodeError{
+		Err: Err{
+			message:  fmt.Sprintf(format+suffix, args...),
+			format:   format + suffix,
+			previous: o.previous,
+			fields:   o.fields,
+		},
+		Code:          code,
+		HTTPCode:      httpCode,
+		BizCode:       bizCode,
+		PublicMessage: o.publicMessage,
+		Retryable:     retryable,
+		RetryAfter:    o.retryAfter,
+	}
+	codeErr.SetLocation(callDepth + 1 + o.callerSkip)
+	codeErr.captureStack(callDepth + 1 + o.callerSkip)
+	return codeErr
+}
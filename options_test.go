@@ -0,0 +1,68 @@
+package errors
+
+import (
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestNew(t *testing.T) {
+	err := New(WithGRPCCode(codes.NotFound), WithCause(io.EOF), WithPublicMessage("order not found"), WithDetails("order_id", 42))
+	inner, ok := AsCodeError(err)
+	if !ok || inner.Code != codes.NotFound || inner.HTTPCode != http.StatusNotFound || inner.BizCode != ErrCodeNotFound.Int() {
+		t.Fatalf("unexpected codes of %#v", err)
+	}
+	if !stderrors.Is(err, io.EOF) || Cause(err) != err {
+		t.Errorf("%#v does not wrap io.EOF", err)
+	}
+	if err.Error() != "EOF" || inner.GetPublicMessage() != "order not found" || Fields(err)["order_id"] != 42 {
+		t.Errorf("unexpected error %q, public message %q, fields %v", err, inner.GetPublicMessage(), Fields(err))
+	}
+	if file, _ := inner.Location(); !strings.HasSuffix(file, "options_test.go") {
+		t.Errorf("location %s is not the caller", file)
+	}
+
+	err = Newf("order %d of %s", 42, WithBizCode(ErrCodeNotFound.Int()), "alice", WithRetryAfter(time.Second))
+	inner, _ = AsCodeError(err)
+	if err.Error() != "order 42 of alice" || inner.Code != codes.NotFound || inner.BizCode != ErrCodeNotFound.Int() {
+		t.Errorf("unexpected error %q with codes %v %d", err, inner.Code, inner.BizCode)
+	}
+	if delay, ok := RetryAfter(err); !ok || delay != time.Second {
+		t.Errorf("RetryAfter = %v, %v", delay, ok)
+	}
+	err = Annotate(New(WithGRPCCode(codes.Unavailable), WithCause(V2MysqlErr(io.EOF))), "get order")
+	if !IsUnavailable(err) || IsInternal(err) || !stderrors.Is(err, io.EOF) {
+		t.Errorf("the codes of the wrapped error leak through %#v", err)
+	}
+	if inner, _ := AsCodeError(newOrderNotFound(42)); inner.Function() != "errors.TestNew" {
+		t.Errorf("error of a helper located in %s", inner.Function())
+	}
+
+	tests := []struct {
+		err       error
+		code      codes.Code
+		httpCode  uint32
+		retryable bool
+	}{
+		{New(), codes.Unknown, http.StatusInternalServerError, false},
+		{New(WithGRPCCode(codes.Unavailable)), codes.Unavailable, http.StatusServiceUnavailable, true},
+		{New(WithGRPCCode(codes.Unavailable), WithRetryable(false)), codes.Unavailable, http.StatusServiceUnavailable, false},
+		{New(WithGRPCCode(codes.Aborted), WithHTTPStatus(http.StatusConflict)), codes.Aborted, http.StatusConflict, true},
+		{New(WithBizCode(ErrCodeNotFound.Int()), WithGRPCCode(codes.Internal)), codes.Internal, http.StatusInternalServerError, false},
+	}
+	for i, tt := range tests {
+		inner, _ := AsCodeError(tt.err)
+		if inner.Code != tt.code || inner.HTTPCode != tt.httpCode || inner.Retryable != tt.retryable {
+			t.Errorf("%d: unexpected codes %v %d %v", i, inner.Code, inner.HTTPCode, inner.Retryable)
+		}
+	}
+}
+
+func newOrderNotFound(id int) error {
+	return Newf("order %d", id, WithGRPCCode(codes.NotFound), WithCallerSkip(1))
+}
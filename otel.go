@@ -0,0 +1,64 @@
+package errors
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+)
+
+// Attribute keys of the codes of the errors recorded by RecordSpanError.
+const (
+	AttributeBizCode    = attribute.Key("error.biz_code")
+	AttributeGRPCCode   = attribute.Key("error.grpc_code")
+	AttributeHTTPStatus = attribute.Key("error.http_status")
+)
+
+// responseErrorKey is the key of the error stored in the gin and hertz
+// contexts by the responders, read by the tracing middlewares.
+const responseErrorKey = "github.com/zhwei820/errors.response_error"
+
+// RecordSpanError records err on the span of ctx: an exception event with
+// the error stack, see ErrorStack, as stack trace and the codes of err as
+// attributes. The span status is only set to Error for the server faults,
+// see isServerFault, as the client faults such as NotFound are part of the
+// normal operation of a service. Nothing is recorded if err is nil or the
+// span is not recording.
+func RecordSpanError(ctx context.Context, err error) {
+	if err == nil {
+		return
+	}
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+	labels := errorLabels("", err, codes.Unknown)
+	attrs := []attribute.KeyValue{
+		AttributeGRPCCode.String(labels.GRPCCode.String()),
+		AttributeHTTPStatus.Int64(int64(labels.HTTPCode)),
+	}
+	if labels.BizCode != 0 {
+		attrs = append(attrs, AttributeBizCode.Int64(int64(labels.BizCode)))
+	}
+	span.SetAttributes(attrs...)
+	span.RecordError(err, trace.WithAttributes(
+		attribute.String("exception.stacktrace", ErrorStack(err)),
+	))
+	if isServerFault(labels.GRPCCode, labels.HTTPCode) {
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+}
+
+// isServerFault reports whether an error with the codes is a fault of the
+// server rather than of the client, following the OpenTelemetry semantic
+// conventions of grpc servers.
+func isServerFault(code codes.Code, httpCode uint32) bool {
+	switch code {
+	case codes.Unknown, codes.DeadlineExceeded, codes.Unimplemented,
+		codes.Internal, codes.Unavailable, codes.DataLoss:
+		return true
+	}
+	return httpCode >= 500
+}
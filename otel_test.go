@@ -0,0 +1,99 @@
+package errors
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+func newTestTracer() (trace.Tracer, *tracetest.InMemoryExporter) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	return provider.Tracer("errors"), exporter
+}
+
+func TestRecordSpanError(t *testing.T) {
+	tracer, exporter := newTestTracer()
+	tests := []struct {
+		err    error
+		status otelcodes.Code
+	}{
+		{NotFoundf("order"), otelcodes.Unset},
+		{Internalf("db"), otelcodes.Error},
+		{Errorf("plain"), otelcodes.Error},
+	}
+	for _, tt := range tests {
+		ctx, span := tracer.Start(context.Background(), "op")
+		RecordSpanError(ctx, tt.err)
+		span.End()
+	}
+	spans := exporter.GetSpans()
+	if len(spans) != len(tests) {
+		t.Fatalf("%d spans exported", len(spans))
+	}
+	for i, tt := range tests {
+		span := spans[i]
+		if span.Status.Code != tt.status {
+			t.Errorf("status of %v = %v, want %v", tt.err, span.Status.Code, tt.status)
+		}
+		if len(span.Events) != 1 || span.Events[0].Name != "exception" {
+			t.Fatalf("events of %v = %+v", tt.err, span.Events)
+		}
+		var stack string
+		for _, attr := range span.Events[0].Attributes {
+			if attr.Key == "exception.stacktrace" {
+				stack = attr.Value.AsString()
+			}
+		}
+		if !strings.Contains(stack, "otel_test.go") {
+			t.Errorf("stack trace of %v = %q", tt.err, stack)
+		}
+	}
+	attrs := map[string]interface{}{}
+	for _, attr := range spans[0].Attributes {
+		attrs[string(attr.Key)] = attr.Value.AsInterface()
+	}
+	if attrs["error.grpc_code"] != "NotFound" || attrs["error.http_status"] != int64(404) || attrs["error.biz_code"] != int64(ErrCodeNotFound) {
+		t.Errorf("unexpected attributes %v", attrs)
+	}
+}
+
+func TestTracingMiddlewares(t *testing.T) {
+	tracer, exporter := newTestTracer()
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(func(g *gin.Context) {
+		ctx, span := tracer.Start(g.Request.Context(), g.FullPath())
+		defer span.End()
+		g.Request = g.Request.WithContext(ctx)
+		g.Next()
+	}, GinTracing())
+	engine.GET("/orders/:id", func(g *gin.Context) { ResponseErr(g, Unavailablef("db")) })
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/orders/1", nil))
+
+	ctx, span := tracer.Start(context.Background(), "/Orders/Get")
+	_, _ = UnaryTracingInterceptor()(ctx, nil, &grpc.UnaryServerInfo{}, func(context.Context, interface{}) (interface{}, error) {
+		return nil, ToGRPCReturnError(NotFoundf("order"))
+	})
+	span.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("%d spans exported", len(spans))
+	}
+	if spans[0].Status.Code != otelcodes.Error || len(spans[0].Events) != 1 {
+		t.Errorf("gin span not recorded: %+v", spans[0])
+	}
+	if spans[1].Status.Code != otelcodes.Unset || len(spans[1].Events) != 1 {
+		t.Errorf("grpc span not recorded: %+v", spans[1])
+	}
+}
@@ -0,0 +1,72 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Recover turns a panic into an Internal CodeError stored in *err. The error
+// carries the panic value and the stack of the panicking goroutine, whether
+// stack capturing is enabled or not. Recover must be deferred directly.
+//
+// For example:
+//
+//	func (s *Svc) Create(ctx context.Context) (err error) {
+//	    defer errors.Recover(&err)
+//	    ...
+//	}
+func Recover(err *error) {
+	if r := recover(); r != nil {
+		*err = newPanicError(r)
+	}
+}
+
+// newPanicError returns an Internal CodeError for the recovered panic value
+// r. The location of the error is the function that panicked.
+func newPanicError(r interface{}) *CodeError {
+	codeErr := &CodeError{
+		Err: Err{
+			message: fmt.Sprintf("panic: %v", r),
+			format:  "panic: %v",
+			fields:  map[string]interface{}{"panic": r},
+			stack:   panicStack(),
+		},
+		Code:     codes.Internal,
+		HTTPCode: http.StatusInternalServerError,
+		BizCode:  ErrCodeInternalServerError.Int(),
+	}
+	if err, ok := r.(error); ok {
+		codeErr.message = "panic"
+		codeErr.format = ""
+		codeErr.previous = err
+	}
+	if frames := codeErr.stack.Frames(); len(frames) > 0 {
+		codeErr.file = frames[0].File
+		codeErr.line = frames[0].Line
+		codeErr.function = shortFuncName(frames[0].Function)
+	}
+	return codeErr
+}
+
+// panicStack captures the stack of the panicking goroutine while a panic is
+// being recovered. The frames of the recovery and of the runtime panic
+// handling are left out, so that the stack starts at the function that
+// panicked.
+func panicStack() *stack {
+	s := callers(1)
+	frames := s.Frames()
+	for i, f := range frames {
+		if f.Function == "runtime.gopanic" {
+			frames = frames[i+1:]
+			break
+		}
+	}
+	for len(frames) > 1 && strings.HasPrefix(frames[0].Function, "runtime.") {
+		frames = frames[1:]
+	}
+	s.frames = frames
+	return s
+}
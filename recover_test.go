@@ -0,0 +1,107 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func panicky() {
+	var m map[string]int
+	m["boom"]++
+}
+
+func TestRecover(t *testing.T) {
+	err := func() (err error) {
+		defer Recover(&err)
+		panicky()
+		return nil
+	}()
+	if !IsInternal(err) || err.Error() != "panic: assignment to entry in nil map" {
+		t.Fatalf("got %v, want an Internal error", err)
+	}
+	frames := Frames(err)
+	if len(frames) == 0 || !strings.HasSuffix(frames[0].Function, ".panicky") {
+		t.Fatalf("stack does not start at the panic: %v", frames)
+	}
+	if !strings.Contains(ErrorStack(err), "(errors.panicky): panic") {
+		t.Fatalf("unexpected error stack:\n%s", ErrorStack(err))
+	}
+}
+
+func TestGinRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(GinRecovery())
+	r.GET("/", func(*gin.Context) { panicky() })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", w.Code)
+	}
+	var body ReturnData
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != ErrCodeInternalServerError.Int() {
+		t.Fatalf("unexpected body %s", w.Body.Bytes())
+	}
+}
+
+func TestHzRecovery(t *testing.T) {
+	serve := func(handler app.HandlerFunc) *app.RequestContext {
+		g := app.NewContext(0)
+		g.SetHandlers(app.HandlersChain{HzRecovery(), handler})
+		g.Next(context.Background())
+		return g
+	}
+	g := serve(func(context.Context, *app.RequestContext) { panicky() })
+	if code := g.Response.StatusCode(); code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want 500", code)
+	}
+	var body ReturnData
+	if err := json.Unmarshal(g.Response.Body(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Code != ErrCodeInternalServerError.Int() || !g.IsAborted() {
+		t.Fatalf("unexpected body %s", g.Response.Body())
+	}
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Fatalf("recovered %v, want http.ErrAbortHandler", r)
+		}
+	}()
+	serve(func(context.Context, *app.RequestContext) { panic(http.ErrAbortHandler) })
+	t.Fatal("http.ErrAbortHandler was swallowed")
+}
+
+func TestRecoveryInterceptors(t *testing.T) {
+	_, err := UnaryRecoveryInterceptor()(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: "/orders.Orders/Get"},
+		func(context.Context, interface{}) (interface{}, error) {
+			panicky()
+			return nil, nil
+		})
+	if status.Code(err) != codes.Internal || !IsInternal(GRPCErrToError(err)) {
+		t.Fatalf("unary interceptor returned %v", err)
+	}
+
+	err = StreamRecoveryInterceptor()(nil, nil, &grpc.StreamServerInfo{FullMethod: "/orders.Orders/List"},
+		func(interface{}, grpc.ServerStream) error {
+			panicky()
+			return nil
+		})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("stream interceptor returned %v", err)
+	}
+}
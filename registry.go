@@ -0,0 +1,228 @@
+package errors
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+// CodeSpec declares a biz code: the grpc code and http status of its errors,
+// its default message per language and free form metadata, e.g. the owner
+// team or a runbook link.
+type CodeSpec struct {
+	BizCode  uint32
+	Service  string // if set, the service ID of BizCode must be allocated to it, see AllocateService
+	GRPCCode codes.Code
+	HTTPCode uint32 // defaults to the http status of GRPCCode, see DefaultCodeTable
+	Messages map[LangType]string
+	Metadata map[string]string
+}
+
+// validate checks spec and fills in the default http status.
+func (spec *CodeSpec) validate() error {
+	if spec.BizCode == OkBizCode {
+		return fmt.Errorf("biz code %d is reserved for success", OkBizCode)
+	}
+	if err := BizCode(spec.BizCode).Validate(); err != nil {
+		return err
+	}
+	if spec.GRPCCode > codes.Unauthenticated {
+		return fmt.Errorf("biz code %d: invalid grpc code %d", spec.BizCode, spec.GRPCCode)
+	}
+	if spec.HTTPCode == 0 {
+		spec.HTTPCode = DefaultCodeTable.HTTPStatus(spec.GRPCCode)
+	}
+	if spec.HTTPCode < 100 || spec.HTTPCode > 599 {
+		return fmt.Errorf("biz code %d: invalid http status %d", spec.BizCode, spec.HTTPCode)
+	}
+	// The biz code errors of NewBizCodeError are OK with a 2xx status, the
+	// other errors must have an error status.
+	if (spec.GRPCCode == codes.OK) != (spec.HTTPCode < http.StatusBadRequest) {
+		return fmt.Errorf("biz code %d: grpc code %s conflicts with http status %d", spec.BizCode, spec.GRPCCode, spec.HTTPCode)
+	}
+	return nil
+}
+
+// Registry holds the declared biz codes. The biz codes of the
+// DefaultRegistry are used by NewBizCodeError and their messages translate
+// the responses, see RegisterI18n.
+type Registry struct {
+	mu       sync.RWMutex
+	specs    map[uint32]CodeSpec
+	services map[uint32]string // service name by service ID
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{specs: map[uint32]CodeSpec{}, services: map[uint32]string{}}
+}
+
+// AllocateService allocates the range of biz codes of the service ID id, see
+// BizCode, to service. A service may be allocated several IDs, an error is
+// returned if id is allocated to another service.
+func (r *Registry) AllocateService(service string, id uint32) error {
+	if service == "" {
+		return fmt.Errorf("service ID %d allocated to an empty service name", id)
+	}
+	if id < MinServiceID || id > MaxServiceID {
+		return fmt.Errorf("service ID %d out of bounds", id)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if owner, ok := r.services[id]; ok && owner != service {
+		return fmt.Errorf("service ID %d already allocated to %s", id, owner)
+	}
+	r.services[id] = service
+	return nil
+}
+
+// ServiceOf returns the service allocated the range of bizCode.
+func (r *Registry) ServiceOf(bizCode uint32) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	service, ok := r.services[BizCode(bizCode).Service()]
+	return service, ok
+}
+
+// CheckServiceCode returns an error if bizCode is not a valid BizCode or not
+// in a range allocated to service, e.g. to check the codes emitted by a
+// service at startup.
+func (r *Registry) CheckServiceCode(service string, bizCode uint32) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.checkServiceCode(service, bizCode)
+}
+
+func (r *Registry) checkServiceCode(service string, bizCode uint32) error {
+	if err := BizCode(bizCode).Validate(); err != nil {
+		return err
+	}
+	id := BizCode(bizCode).Service()
+	owner, ok := r.services[id]
+	switch {
+	case !ok:
+		return fmt.Errorf("biz code %s: service ID %d is not allocated to %s", BizCode(bizCode), id, service)
+	case owner != service:
+		return fmt.Errorf("biz code %s: service ID %d is allocated to %s, not %s", BizCode(bizCode), id, owner, service)
+	}
+	return nil
+}
+
+// Register declares the biz codes of specs. An error is returned, and none of
+// specs is registered, if a biz code is already registered, declared twice or
+// invalid, e.g. a grpc code which conflicts with the http status or a code
+// out of the ranges allocated to the service of the spec.
+func (r *Registry) Register(specs ...CodeSpec) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// The specs are validated as copies, so that the defaults filled in by
+	// validate don't leak into the slice of the caller.
+	validated := make(map[uint32]CodeSpec, len(specs))
+	for _, spec := range specs {
+		if err := spec.validate(); err != nil {
+			return err
+		}
+		if spec.Service != "" {
+			if err := r.checkServiceCode(spec.Service, spec.BizCode); err != nil {
+				return err
+			}
+		}
+		_, registered := r.specs[spec.BizCode]
+		_, declared := validated[spec.BizCode]
+		if registered || declared {
+			return fmt.Errorf("biz code %d already registered", spec.BizCode)
+		}
+		validated[spec.BizCode] = spec
+	}
+	for bizCode, spec := range validated {
+		r.specs[bizCode] = spec
+	}
+	return nil
+}
+
+// MustRegister is like Register but panics on error. It returns true so that
+// it can be used in a variable initializer, e.g. from init time.
+func (r *Registry) MustRegister(specs ...CodeSpec) bool {
+	if err := r.Register(specs...); err != nil {
+		panic(err)
+	}
+	return true
+}
+
+// Lookup returns the spec of bizCode.
+func (r *Registry) Lookup(bizCode uint32) (CodeSpec, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	spec, ok := r.specs[bizCode]
+	return spec, ok
+}
+
+// Specs returns the registered specs ordered by biz code.
+func (r *Registry) Specs() []CodeSpec {
+	r.mu.RLock()
+	specs := make([]CodeSpec, 0, len(r.specs))
+	for _, spec := range r.specs {
+		specs = append(specs, spec)
+	}
+	r.mu.RUnlock()
+	sort.Slice(specs, func(i, k int) bool { return specs[i].BizCode < specs[k].BizCode })
+	return specs
+}
+
+// message returns the default message of bizCode in lang.
+func (r *Registry) message(lang LangType, bizCode uint32) (string, bool) {
+	spec, ok := r.Lookup(bizCode)
+	if !ok {
+		return "", false
+	}
+	msg, ok := spec.Messages[lang]
+	return msg, ok
+}
+
+// DefaultRegistry is the registry of the preset biz codes, see ErrCode, and
+// of the biz codes declared with Register.
+var DefaultRegistry = NewRegistry()
+
+// Register declares biz codes in the DefaultRegistry, see Registry.Register.
+// For example:
+//
+//	var _ = errors.MustRegister(errors.CodeSpec{
+//	    BizCode:  1200010001,
+//	    GRPCCode: codes.FailedPrecondition,
+//	    Messages: map[errors.LangType]string{errors.EnUs: "insufficient balance", errors.ZhCn: "余额不足"},
+//	})
+func Register(specs ...CodeSpec) error {
+	return DefaultRegistry.Register(specs...)
+}
+
+// MustRegister declares biz codes in the DefaultRegistry and panics on
+// error, see Registry.MustRegister.
+func MustRegister(specs ...CodeSpec) bool {
+	return DefaultRegistry.MustRegister(specs...)
+}
+
+// AllocateService allocates a range of biz codes to service in the
+// DefaultRegistry, see Registry.AllocateService.
+func AllocateService(service string, id uint32) error {
+	return DefaultRegistry.AllocateService(service, id)
+}
+
+// CheckServiceCode checks bizCode is in a range allocated to service in the
+// DefaultRegistry, see Registry.CheckServiceCode.
+func CheckServiceCode(service string, bizCode uint32) error {
+	return DefaultRegistry.CheckServiceCode(service, bizCode)
+}
+
+// LookupCode returns the spec of bizCode in the DefaultRegistry. The http
+// status of the preset biz codes, e.g. ErrCodeNotFound, is the one of their
+// grpc code in the DefaultCodeTable, so that it follows CodeTable.Set.
+func LookupCode(bizCode uint32) (CodeSpec, bool) {
+	spec, ok := DefaultRegistry.Lookup(bizCode)
+	if ok && presetBizCodes[bizCode] {
+		spec.HTTPCode = DefaultCodeTable.HTTPStatus(spec.GRPCCode)
+	}
+	return spec, ok
+}
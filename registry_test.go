@@ -0,0 +1,130 @@
+package errors
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	spec := CodeSpec{BizCode: 1200010001, GRPCCode: codes.FailedPrecondition, Messages: map[LangType]string{EnUs: "insufficient balance"}}
+	specs := []CodeSpec{spec}
+	if err := r.Register(specs...); err != nil {
+		t.Fatal(err)
+	}
+	if specs[0].HTTPCode != 0 {
+		t.Errorf("Register changed the spec of the caller: %+v", specs[0])
+	}
+	if got, ok := r.Lookup(spec.BizCode); !ok || got.HTTPCode != http.StatusPreconditionFailed {
+		t.Fatalf("Lookup = %+v, %v", got, ok)
+	}
+
+	tests := []struct {
+		specs []CodeSpec
+		want  string
+	}{
+		{[]CodeSpec{spec}, "already registered"},
+		{[]CodeSpec{{BizCode: 1200010002}, {BizCode: 1200010002}}, "already registered"},
+		{[]CodeSpec{{BizCode: OkBizCode}}, "reserved"},
+		{[]CodeSpec{{BizCode: 1200010003, GRPCCode: codes.NotFound, HTTPCode: http.StatusOK}}, "conflicts"},
+		{[]CodeSpec{{BizCode: 1200010004, HTTPCode: 700}}, "invalid http status"},
+	}
+	for _, tt := range tests {
+		if err := r.Register(tt.specs...); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Register(%+v) = %v, want %q", tt.specs, err, tt.want)
+		}
+	}
+	if _, ok := r.Lookup(1200010002); ok {
+		t.Error("a failed Register registered some specs")
+	}
+	if n := len(r.Specs()); n != 1 {
+		t.Errorf("%d specs registered, want 1", n)
+	}
+
+	if !NewRegistry().MustRegister(spec) {
+		t.Error("Registry.MustRegister returned false")
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("MustRegister doesn't panic on duplicates")
+		}
+	}()
+	MustRegister(CodeSpec{BizCode: ErrCodeNotFound.Int(), GRPCCode: codes.NotFound})
+}
+
+func TestNewBizCodeErrorRegistry(t *testing.T) {
+	err := NewBizCodeError(ErrCodeNotFound.Int())
+	if !IsNotFound(err) || !IsAnyBizCodeErr(err) {
+		t.Errorf("unexpected codes of %#v", err)
+	}
+	if Fields(NewBizCodeError(42)) != nil {
+		t.Error("a legacy biz code is flagged")
+	}
+	inner, _ := AsCodeError(NewBizCodeErrorf(1200019999, "undeclared"))
+	if inner.Code != codes.OK || inner.HTTPCode != http.StatusOK || !IsAnyBizCodeErr(inner) {
+		t.Errorf("unexpected codes of %#v", inner)
+	}
+	if IsAnyBizCodeErr(NotFoundf("order")) {
+		t.Error("NotFoundf is not a biz code error")
+	}
+	if msg := getTranslateMsgByLang("en", MysqlErrorBizCode); msg != "network error" {
+		t.Errorf("registry message not used, got %q", msg)
+	}
+}
+
+func TestBizCode(t *testing.T) {
+	code := MustComposeBizCode(1200, 1, 301)
+	if code.Int() != MysqlErrorBizCode || code.Service() != 1200 || code.Module() != 1 || code.Sequence() != 301 {
+		t.Fatalf("unexpected parts of %d", code)
+	}
+	short := BizCode(ErrCodeNotFound)
+	if short.Service() != code.Service() || short.Module() != 0 || short.Sequence() != 5 {
+		t.Fatalf("unexpected parts of the short code %d", short)
+	}
+	if s := short.String(); s != "1200-0005" {
+		t.Errorf("String() = %q", s)
+	}
+	for _, s := range []string{"1200-0005", "12000005"} {
+		if parsed, err := ParseBizCode(s); err != nil || parsed != short {
+			t.Errorf("ParseBizCode(%q) = %d, %v", s, parsed, err)
+		}
+	}
+	if parsed, err := ParseBizCode(code.String()); err != nil || parsed != code {
+		t.Errorf("ParseBizCode(%q) = %d, %v", code, parsed, err)
+	}
+	for _, s := range []string{"12-000-005", "1200-1000-5", "4295-000-000", "1200-x-5", "1200-10000", "100000101"} {
+		if _, err := ParseBizCode(s); err == nil {
+			t.Errorf("ParseBizCode(%q) succeeded", s)
+		}
+	}
+}
+
+func TestAllocateService(t *testing.T) {
+	r := NewRegistry()
+	if err := r.AllocateService("order", 1201); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.AllocateService("payment", 1201); err == nil {
+		t.Error("service ID allocated twice")
+	}
+	inRange := MustComposeBizCode(1201, 2, 1).Int()
+	outOfRange := MustComposeBizCode(1202, 2, 1).Int()
+	if service, ok := r.ServiceOf(inRange); !ok || service != "order" {
+		t.Errorf("ServiceOf(%d) = %q, %v", inRange, service, ok)
+	}
+	if err := r.CheckServiceCode("order", outOfRange); err == nil {
+		t.Error("code out of range accepted")
+	}
+	if err := r.CheckServiceCode("order", 40401); err == nil {
+		t.Error("invalid code accepted")
+	}
+	if err := r.Register(CodeSpec{BizCode: outOfRange, Service: "order", GRPCCode: codes.NotFound}); err == nil {
+		t.Error("code out of range registered")
+	}
+	if err := r.Register(CodeSpec{BizCode: inRange, Service: "order", GRPCCode: codes.NotFound}); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,364 @@
+package errors
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// Report is an error delivered to the reporters by a Dispatcher.
+type Report struct {
+	Time        time.Time
+	Endpoint    string // the route of gin and hertz, "" for grpc
+	Fingerprint string // see Fingerprint
+	Err         error
+}
+
+// MarshalJSON implements json.Marshaler, the error is encoded by its
+// MarshalJSON if any, see jsonError:
+//
+//	{"time": "2023-04-01T12:00:00Z", "endpoint": "/orders/:id", "fingerprint": "5c0ce8b1e2e4b0f6", "error": {...}}
+func (r *Report) MarshalJSON() ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if m, ok := r.Err.(json.Marshaler); ok {
+		data, err = m.MarshalJSON()
+	} else {
+		data, err = json.Marshal(newJSONError(r.Err))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		Time        time.Time       `json:"time"`
+		Endpoint    string          `json:"endpoint,omitempty"`
+		Fingerprint string          `json:"fingerprint"`
+		Error       json.RawMessage `json:"error"`
+	}{r.Time, r.Endpoint, r.Fingerprint, data})
+}
+
+// Reporter delivers the reported errors, e.g. to a file or an error tracker.
+// It is called by a single goroutine of the Dispatcher.
+type Reporter interface {
+	Report(report *Report) error
+}
+
+// ReporterFunc adapts a function to the Reporter interface.
+type ReporterFunc func(report *Report) error
+
+// Report implements Reporter.
+func (f ReporterFunc) Report(report *Report) error {
+	return f(report)
+}
+
+// DispatcherOption configures a Dispatcher.
+type DispatcherOption func(*Dispatcher)
+
+// WithSampleRate reports the given fraction of the errors, between 0 and 1.
+// All the errors are reported by default.
+func WithSampleRate(rate float64) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.sampleRate = rate
+	}
+}
+
+// WithRateLimit reports at most n errors with the same fingerprint per
+// period. The errors are not rate limited by default.
+func WithRateLimit(n int, period time.Duration) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.rateLimit, d.ratePeriod = n, period
+	}
+}
+
+// WithBufferSize sets the number of errors buffered before they are
+// delivered, 1024 by default. The errors dispatched while the buffer is full
+// are dropped.
+func WithBufferSize(n int) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.bufferSize = n
+	}
+}
+
+// WithReportErrorHandler sets the function called with the errors returned
+// by the reporters, they are ignored by default.
+func WithReportErrorHandler(handler func(error)) DispatcherOption {
+	return func(d *Dispatcher) {
+		d.onError = handler
+	}
+}
+
+// maxRateLimitKeys is the number of fingerprints tracked by the rate limiter
+// above which the expired ones are evicted.
+const maxRateLimitKeys = 10000
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// Dispatcher samples, rate limits and buffers the errors, and delivers them
+// asynchronously to its reporters. It receives the server faults returned
+// through the responders and the grpc interceptors once set with
+// SetDispatcher. It must be closed with Close to deliver the buffered errors.
+type Dispatcher struct {
+	reporters  []Reporter
+	sampleRate float64
+	rateLimit  int
+	ratePeriod time.Duration
+	bufferSize int
+	onError    func(error)
+
+	mu      sync.RWMutex // guards closed against the sends on queue
+	closed  bool
+	queue   chan interface{} // *Report or the chan struct{} of a flush
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	limiterMu sync.Mutex
+	windows   map[string]*rateWindow
+}
+
+// NewDispatcher returns a started Dispatcher delivering to reporters.
+func NewDispatcher(reporters []Reporter, opts ...DispatcherOption) *Dispatcher {
+	d := &Dispatcher{
+		reporters:  reporters,
+		sampleRate: 1,
+		bufferSize: 1024,
+		windows:    map[string]*rateWindow{},
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	d.queue = make(chan interface{}, d.bufferSize)
+	go d.run()
+	return d
+}
+
+// Dispatch queues err, returned on endpoint, for delivery. It never blocks:
+// false is returned if err is sampled out, rate limited, or dropped because
+// the buffer is full or the dispatcher is closed.
+func (d *Dispatcher) Dispatch(endpoint string, err error) bool {
+	if err == nil || d.sampleRate < 1 && rand.Float64() >= d.sampleRate {
+		return false
+	}
+	report := &Report{Time: time.Now(), Endpoint: endpoint, Fingerprint: Fingerprint(err), Err: err}
+	if !d.allow(report.Fingerprint, report.Time) {
+		return false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if !d.closed {
+		select {
+		case d.queue <- report:
+			return true
+		default:
+		}
+	}
+	d.dropped.Add(1)
+	return false
+}
+
+// Dropped returns the number of errors dropped because the buffer was full
+// or the dispatcher closed.
+func (d *Dispatcher) Dropped() uint64 {
+	return d.dropped.Load()
+}
+
+// allow reports whether an error with fingerprint is within the rate limit.
+func (d *Dispatcher) allow(fingerprint string, now time.Time) bool {
+	if d.rateLimit <= 0 {
+		return true
+	}
+	d.limiterMu.Lock()
+	defer d.limiterMu.Unlock()
+	w, ok := d.windows[fingerprint]
+	if !ok || now.Sub(w.start) >= d.ratePeriod {
+		if !ok && len(d.windows) >= maxRateLimitKeys {
+			for key, w := range d.windows {
+				if now.Sub(w.start) >= d.ratePeriod {
+					delete(d.windows, key)
+				}
+			}
+		}
+		w = &rateWindow{start: now}
+		d.windows[fingerprint] = w
+	}
+	w.count++
+	return w.count <= d.rateLimit
+}
+
+func (d *Dispatcher) run() {
+	defer close(d.done)
+	for item := range d.queue {
+		switch item := item.(type) {
+		case *Report:
+			for _, reporter := range d.reporters {
+				if err := reporter.Report(item); err != nil && d.onError != nil {
+					d.onError(err)
+				}
+			}
+		case chan struct{}:
+			close(item)
+		}
+	}
+}
+
+// Flush waits until the errors dispatched before the call are delivered, or
+// ctx is done.
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	flushed := make(chan struct{})
+	d.mu.RLock()
+	if d.closed {
+		d.mu.RUnlock()
+		return nil
+	}
+	select {
+	case d.queue <- flushed:
+	case <-ctx.Done():
+		d.mu.RUnlock()
+		return ctx.Err()
+	}
+	d.mu.RUnlock()
+	select {
+	case <-flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops the dispatcher and waits until the buffered errors are
+// delivered, or ctx is done. The errors dispatched afterwards are dropped.
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.queue)
+	}
+	d.mu.Unlock()
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var dispatcher atomic.Pointer[Dispatcher]
+
+// SetDispatcher sets the dispatcher receiving the server faults, i.e. the
+// errors with an Unknown, Internal or another server side grpc code or a 5xx
+// http status, returned through ResponseErr, HzResponseErr and
+// ToGRPCReturnError, and so the recovery interceptors. A nil dispatcher
+// disables the reporting, which is the default. The previous dispatcher is
+// returned. For example:
+//
+//	d := errors.NewDispatcher([]errors.Reporter{errors.NewSlogReporter(slog.Default())},
+//	    errors.WithRateLimit(10, time.Minute))
+//	errors.SetDispatcher(d)
+//	defer d.Close(context.Background())
+func SetDispatcher(d *Dispatcher) *Dispatcher {
+	return dispatcher.Swap(d)
+}
+
+// reportError dispatches err, returned on endpoint, if it is a server fault.
+func reportError(endpoint string, err error, code codes.Code) {
+	d := dispatcher.Load()
+	if d == nil || err == nil {
+		return
+	}
+	if labels := errorLabels(endpoint, err, code); isServerFault(labels.GRPCCode, labels.HTTPCode) {
+		d.Dispatch(endpoint, err)
+	}
+}
+
+// FileReporter is a Reporter appending the reports to a file as JSON lines.
+type FileReporter struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileReporter returns a FileReporter appending to the file at path,
+// created if needed.
+func NewFileReporter(path string) (*FileReporter, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &FileReporter{file: file}, nil
+}
+
+// Report implements Reporter.
+func (r *FileReporter) Report(report *Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err = r.file.Write(append(data, '\n'))
+	return err
+}
+
+// Close closes the file.
+func (r *FileReporter) Close() error {
+	return r.file.Close()
+}
+
+// NewSlogReporter returns a Reporter logging the reports at the error level,
+// the error is logged under the key "error", see CodeError.LogValue.
+func NewSlogReporter(logger *slog.Logger) Reporter {
+	return ReporterFunc(func(report *Report) error {
+		logger.LogAttrs(context.Background(), slog.LevelError, "error reported",
+			slog.String("endpoint", report.Endpoint),
+			slog.String("fingerprint", report.Fingerprint),
+			slog.Any("error", report.Err),
+		)
+		return nil
+	})
+}
+
+// webhookTimeout bounds the requests of the default client of
+// NewWebhookReporter.
+const webhookTimeout = 5 * time.Second
+
+// NewWebhookReporter returns a Reporter posting the reports as JSON to url
+// with client. A nil client is an http.Client with a 5s timeout: the reports
+// are delivered one at a time, so that a hung webhook would block the other
+// reporters of the Dispatcher. A response status other than 2xx is returned
+// as an error.
+func NewWebhookReporter(url string, client *http.Client) Reporter {
+	if client == nil {
+		client = &http.Client{Timeout: webhookTimeout}
+	}
+	return ReporterFunc(func(report *Report) error {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Post(url, "application/json", bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("webhook %s: %s", url, resp.Status)
+		}
+		return nil
+	})
+}
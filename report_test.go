@@ -0,0 +1,122 @@
+package errors
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcher(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		reports []*Report
+	)
+	collect := ReporterFunc(func(report *Report) error {
+		mu.Lock()
+		reports = append(reports, report)
+		mu.Unlock()
+		return nil
+	})
+	d := NewDispatcher([]Reporter{collect}, WithRateLimit(2, time.Hour))
+	defer SetDispatcher(SetDispatcher(d))
+
+	for i := 0; i < 5; i++ {
+		_ = ToGRPCReturnError(Internalf("db down")) // same fingerprint
+	}
+	_ = ToGRPCReturnError(Unavailablef("cache down"))
+	_ = ToGRPCReturnError(NotFoundf("order")) // client fault
+	if err := d.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	mu.Lock()
+	if len(reports) != 3 {
+		t.Fatalf("%d errors reported, want 3", len(reports))
+	}
+	mu.Unlock()
+
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if d.Dispatch("", Internalf("late")) || d.Dropped() != 1 {
+		t.Errorf("dispatched after close, %d dropped", d.Dropped())
+	}
+
+	sampled := NewDispatcher(nil, WithSampleRate(0))
+	defer sampled.Close(context.Background())
+	if sampled.Dispatch("", Internalf("db down")) {
+		t.Error("error not sampled out")
+	}
+}
+
+func TestReporters(t *testing.T) {
+	var posted []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+
+	path := filepath.Join(t.TempDir(), "errors.log")
+	file, err := NewFileReporter(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	var logged bytes.Buffer
+	var failures []error
+
+	d := NewDispatcher([]Reporter{
+		file,
+		NewSlogReporter(slog.New(slog.NewJSONHandler(&logged, nil))),
+		NewWebhookReporter(server.URL, nil),
+		NewWebhookReporter(failing.URL, server.Client()),
+	}, WithReportErrorHandler(func(err error) { failures = append(failures, err) }))
+	d.Dispatch("/orders/:id", Internalf("db down"))
+	if err := d.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report struct {
+		Endpoint string `json:"endpoint"`
+		Error    struct {
+			Message  string `json:"message"`
+			GRPCCode string `json:"grpc_code"`
+		} `json:"error"`
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if report.Endpoint != "/orders/:id" || report.Error.GRPCCode != "Internal" || !strings.HasPrefix(report.Error.Message, "db down") {
+		t.Errorf("unexpected file report %s", data)
+	}
+	if !bytes.Equal(bytes.TrimSpace(data), posted) {
+		t.Errorf("webhook received %s, want %s", posted, data)
+	}
+	if !strings.Contains(logged.String(), `"fingerprint"`) {
+		t.Errorf("unexpected log %s", logged.String())
+	}
+	if len(failures) != 1 || !strings.Contains(failures[0].Error(), "502") {
+		t.Errorf("unexpected report errors %v", failures)
+	}
+}
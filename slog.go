@@ -0,0 +1,140 @@
+package errors
+
+import (
+	"context"
+	innerErr "errors"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	_ slog.LogValuer = (*Err)(nil)
+	_ slog.LogValuer = (*CodeError)(nil)
+)
+
+// LogValue implements slog.LogValuer. The error is logged as a group holding
+// its message, location, error stack and fields.
+func (e *Err) LogValue() slog.Value {
+	return slog.GroupValue(errorAttrs(e)...)
+}
+
+// LogValue implements slog.LogValuer. The error is logged as a group holding
+// its message, location, error stack, fields and codes.
+func (c *CodeError) LogValue() slog.Value {
+	return slog.GroupValue(errorAttrs(c)...)
+}
+
+// errorAttrs returns the attributes describing err: its message, most recent
+// location, error stack, grpc/http/biz codes, fields and fingerprint.
+func errorAttrs(err error) []slog.Attr {
+	attrs := []slog.Attr{slog.String("message", err.Error())}
+	var l locationer
+	if innerErr.As(err, &l) {
+		if file, line := l.Location(); file != "" {
+			attrs = append(attrs, slog.String("location", fmt.Sprintf("%s:%d", trimSourcePath(file), line)))
+		}
+		if f, ok := l.(functionLocationer); ok && f.Function() != "" {
+			attrs = append(attrs, slog.String("function", f.Function()))
+		}
+	}
+	// The error stack starts at the first annotated error of the chain, so
+	// errors wrapped by fmt.Errorf("%w") still show their stack.
+	stackErr := err
+	var w wrapper
+	if innerErr.As(err, &w) {
+		stackErr = w.(error)
+	}
+	attrs = append(attrs, slog.Any("stack", errorStack(stackErr)))
+	if inner, ok := AsCodeError(err); ok {
+		attrs = append(attrs,
+			slog.String("grpc_code", inner.Code.String()),
+			slog.Any("http_code", inner.HTTPCode),
+			slog.Any("biz_code", inner.BizCode),
+		)
+	}
+	if fields := Fields(err); len(fields) > 0 {
+		group := make([]any, 0, len(fields))
+		for _, k := range sortedKeys(fields) {
+			group = append(group, slog.Any(k, fields[k]))
+		}
+		attrs = append(attrs, slog.Group("fields", group...))
+	}
+	attrs = append(attrs, slog.String("fingerprint", Fingerprint(err)))
+	return attrs
+}
+
+// isPackageError reports whether the error chain of err holds an error built
+// by this package.
+func isPackageError(err error) bool {
+	if _, ok := AsCodeError(err); ok {
+		return true
+	}
+	var e *Err
+	var m *MultiError
+	return innerErr.As(err, &e) || innerErr.As(err, &m)
+}
+
+// SlogHandler is a slog.Handler expanding every error attribute built by
+// this package into a group, see NewSlogHandler.
+type SlogHandler struct {
+	handler slog.Handler
+}
+
+// NewSlogHandler returns a slog.Handler which expands the error attributes
+// whose error chain holds an error of this package, including errors wrapped
+// by fmt.Errorf, into a group of message, location, stack, codes and fields
+// before passing the record to h.
+//
+// For example:
+//
+//	logger := slog.New(errors.NewSlogHandler(slog.NewJSONHandler(os.Stderr, nil)))
+//	logger.Error("create order failed", "err", err)
+func NewSlogHandler(h slog.Handler) *SlogHandler {
+	return &SlogHandler{handler: h}
+}
+
+// Enabled implements slog.Handler.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle implements slog.Handler.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	expanded := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		expanded.AddAttrs(expandErrorAttr(a))
+		return true
+	})
+	return h.handler.Handle(ctx, expanded)
+}
+
+// WithAttrs implements slog.Handler.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	expanded := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		expanded[i] = expandErrorAttr(a)
+	}
+	return &SlogHandler{handler: h.handler.WithAttrs(expanded)}
+}
+
+// WithGroup implements slog.Handler.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	return &SlogHandler{handler: h.handler.WithGroup(name)}
+}
+
+func expandErrorAttr(a slog.Attr) slog.Attr {
+	switch a.Value.Kind() {
+	case slog.KindAny, slog.KindLogValuer:
+		if err, ok := a.Value.Any().(error); ok && err != nil && isPackageError(err) {
+			return slog.Attr{Key: a.Key, Value: slog.GroupValue(errorAttrs(err)...)}
+		}
+	case slog.KindGroup:
+		group := a.Value.Group()
+		expanded := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			expanded[i] = expandErrorAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(expanded...)}
+	}
+	return a
+}
@@ -0,0 +1,47 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := SetSourceTrimPrefix(wd + string(os.PathSeparator))
+	defer SetSourceTrimPrefix(previous)
+
+	var buf bytes.Buffer
+	logger := slog.New(NewSlogHandler(slog.NewJSONHandler(&buf, nil)))
+	cause := WithFields(NotFoundf("order %d", 42), "order_id", 42)
+	logger.Error("load order", "err", fmt.Errorf("handler: %w", cause))
+
+	var record struct {
+		Err struct {
+			Message  string
+			Location string
+			Stack    []string
+			GRPCCode string `json:"grpc_code"`
+			BizCode  uint32 `json:"biz_code"`
+			Fields   map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("%v: %s", err, buf.Bytes())
+	}
+	got := record.Err
+	if got.Message != "handler: order 42 Not Found" || got.GRPCCode != "NotFound" ||
+		got.BizCode != ErrCodeNotFound.Int() || got.Fields["order_id"] != float64(42) {
+		t.Fatalf("unexpected record %s", buf.Bytes())
+	}
+	if !strings.HasPrefix(got.Location, "slog_test.go:") || !strings.HasPrefix(got.Stack[0], "slog_test.go:") {
+		t.Fatalf("source path is not trimmed: %s", buf.Bytes())
+	}
+}
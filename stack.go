@@ -0,0 +1,129 @@
+package errors
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxStackDepth is the maximum number of frames captured for an error.
+const maxStackDepth = 32
+
+var stackCapture atomic.Bool
+
+// SetStackCapture enables or disables capturing the full call stack when an
+// error is created by Errorf or one of the CodeError constructors. Capturing
+// is disabled by default because it costs a runtime.Callers call per error.
+// The previous setting is returned.
+func SetStackCapture(enabled bool) bool {
+	return stackCapture.Swap(enabled)
+}
+
+// Frame is a single entry of a captured call stack.
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// String returns the frame as "function file:line".
+func (f Frame) String() string {
+	return fmt.Sprintf("%s %s:%d", f.Function, f.File, f.Line)
+}
+
+// stack holds the program counters of a captured call stack. The program
+// counters are only symbolized the first time the frames are requested.
+type stack struct {
+	pcs    []uintptr
+	once   sync.Once
+	frames []Frame
+}
+
+// callers captures the call stack starting at skip stack frames above the
+// caller of callers.
+func callers(skip int) *stack {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	if n == 0 {
+		return nil
+	}
+	return &stack{pcs: append([]uintptr(nil), pcs[:n]...)}
+}
+
+func (s *stack) Frames() []Frame {
+	if s == nil {
+		return nil
+	}
+	s.once.Do(func() {
+		frames := runtime.CallersFrames(s.pcs)
+		for {
+			frame, more := frames.Next()
+			s.frames = append(s.frames, Frame{
+				Function: frame.Function,
+				File:     trimSourcePath(frame.File),
+				Line:     frame.Line,
+			})
+			if !more {
+				break
+			}
+		}
+	})
+	return s.frames
+}
+
+// Frames returns the call stack captured where the error was created, or nil
+// if stack capturing was disabled at that time. See SetStackCapture.
+func (e *Err) Frames() []Frame {
+	return e.stack.Frames()
+}
+
+// captureStack records the call stack at callDepth stack frames above the
+// call, if stack capturing is enabled.
+func (e *Err) captureStack(callDepth int) {
+	if stackCapture.Load() {
+		e.stack = callers(callDepth + 1)
+	}
+}
+
+type framer interface {
+	Frames() []Frame
+}
+
+// Frames returns the call stack captured where the original error in the
+// error stack was created. The annotation stack is walked from the outermost
+// error and the deepest captured stack wins, as it is the closest to the
+// origin of the failure.
+func Frames(err error) []Frame {
+	var frames []Frame
+	for err != nil {
+		if f, ok := err.(framer); ok {
+			if stack := f.Frames(); len(stack) > 0 {
+				frames = stack
+			}
+		}
+		w, ok := err.(wrapper)
+		if !ok {
+			break
+		}
+		err = w.Underlying()
+	}
+	return frames
+}
+
+// formatFrames renders frames one function per line followed by its
+// indented source location, as used by the %+v verb.
+func formatFrames(frames []Frame) string {
+	var b strings.Builder
+	for _, f := range frames {
+		b.WriteString("\n")
+		b.WriteString(f.Function)
+		b.WriteString("\n\t")
+		b.WriteString(f.File)
+		b.WriteString(":")
+		b.WriteString(strconv.Itoa(f.Line))
+	}
+	return b.String()
+}
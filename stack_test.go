@@ -0,0 +1,28 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFrames(t *testing.T) {
+	if Frames(Internalf("no stack")) != nil {
+		t.Fatal("stack captured while capturing is disabled")
+	}
+
+	previous := SetStackCapture(true)
+	defer SetStackCapture(previous)
+
+	err := Annotate(Internalf("db down"), "create order")
+	frames := Frames(err)
+	if len(frames) == 0 {
+		t.Fatal("no frames captured")
+	}
+	if !strings.HasSuffix(frames[0].Function, "TestFrames") {
+		t.Fatalf("first frame = %s, want TestFrames", frames[0])
+	}
+	if out := fmt.Sprintf("%+v", err); !strings.Contains(out, frames[0].Function+"\n\t") {
+		t.Fatalf("%%+v output misses the call stack:\n%s", out)
+	}
+}
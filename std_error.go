@@ -0,0 +1,139 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	innerErr "errors"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc/status"
+)
+
+// Classifier converts err into a CodeError, e.g. with NewNotFound. It returns
+// nil if it doesn't know err.
+type Classifier func(err error) error
+
+var classifiers struct {
+	sync.RWMutex
+	list []Classifier
+}
+
+// RegisterClassifier adds a classifier to FromError. The registered
+// classifiers are consulted before the built-in ones, the most recently
+// registered first, so a team can take over the classification of any
+// error, e.g. its driver errors.
+func RegisterClassifier(c Classifier) {
+	classifiers.Lock()
+	defer classifiers.Unlock()
+	classifiers.list = append(classifiers.list, c)
+}
+
+// FromError normalizes err into a CodeError. The original error is kept as
+// the cause, so errors.Is(FromError(err), context.Canceled) still holds. The
+// registered classifiers are consulted first, see RegisterClassifier, then
+// the standard library and runtime errors are classified:
+//
+//	context.Canceled                          Canceled            499
+//	context.DeadlineExceeded, timeouts        DeadlineExceeded    504
+//	*net.OpError, *net.DNSError, *url.Error   Unavailable         503
+//	fs.ErrNotExist                            NotFound            404
+//	fs.ErrPermission                          PermissionDenied    403
+//	fs.ErrExist                               AlreadyExists       409
+//	*json.SyntaxError, *json.UnmarshalTypeError,
+//	*strconv.NumError                         InvalidArgument     400
+//	grpc status errors                        see GRPCErrToError
+//
+// Errors which already hold a CodeError and the unknown errors are returned
+// unchanged.
+func FromError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := AsCodeError(err); ok {
+		return err
+	}
+	classifiers.RLock()
+	for i := len(classifiers.list) - 1; i >= 0; i-- {
+		if classified := classifiers.list[i](err); classified != nil {
+			classifiers.RUnlock()
+			return classified
+		}
+	}
+	classifiers.RUnlock()
+	if codeErr := classifyStdError(err); codeErr != nil {
+		codeErr.SetLocation(1)
+		return codeErr
+	}
+	return err
+}
+
+// classifyStdError returns the CodeError of the standard library and runtime
+// errors, or nil if err is not one of them.
+func classifyStdError(err error) *CodeError {
+	var (
+		syntaxErr    *json.SyntaxError
+		unmarshalErr *json.UnmarshalTypeError
+		numErr       *strconv.NumError
+	)
+	switch {
+	case innerErr.Is(err, context.Canceled):
+		return classified(NewCanceled, err)
+	case innerErr.Is(err, context.DeadlineExceeded), innerErr.Is(err, os.ErrDeadlineExceeded):
+		return classified(NewDeadlineExceeded, err)
+	case innerErr.Is(err, fs.ErrNotExist):
+		return classified(NewNotFound, err)
+	case innerErr.Is(err, fs.ErrPermission):
+		return classified(NewForbidden, err)
+	case innerErr.Is(err, fs.ErrExist):
+		return classified(NewAlreadyExists, err)
+	}
+	if netErr, ok := networkError(err); ok {
+		if netErr.Timeout() {
+			return classified(NewDeadlineExceeded, err)
+		}
+		return classified(NewUnavailable, err)
+	}
+	switch {
+	case innerErr.As(err, &syntaxErr), innerErr.As(err, &unmarshalErr), innerErr.As(err, &numErr):
+		return classified(NewNotValid, err)
+	}
+	if _, ok := status.FromError(err); ok {
+		codeErr := GRPCErrToError(err).(*CodeError)
+		codeErr.Err = Err{cause: err, previous: err, fields: codeErr.fields}
+		return codeErr
+	}
+	return nil
+}
+
+// networkError returns the network error of err: a *net.OpError,
+// *net.DNSError or *url.Error. The other errors implementing net.Error, e.g.
+// the syscall.Errno of a *fs.PathError, aren't network errors.
+func networkError(err error) (net.Error, bool) {
+	var (
+		opErr  *net.OpError
+		dnsErr *net.DNSError
+		urlErr *url.Error
+	)
+	switch {
+	case innerErr.As(err, &urlErr):
+		return urlErr, true
+	case innerErr.As(err, &opErr):
+		return opErr, true
+	case innerErr.As(err, &dnsErr):
+		return dnsErr, true
+	}
+	return nil, false
+}
+
+// classified returns the CodeError built by newErr, e.g. NewNotFound, caused
+// by err. The location is left to the caller.
+func classified(newErr func(err error, msg string) error, err error) *CodeError {
+	codeErr := newErr(err, "").(*CodeError)
+	codeErr.cause = err
+	return codeErr
+}
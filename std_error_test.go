@@ -0,0 +1,95 @@
+package errors
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestFromError(t *testing.T) {
+	_, numErr := strconv.Atoi("x")
+	_, openErr := os.Open(filepath.Join(t.TempDir(), "missing"))
+	dir := t.TempDir()
+	mkdirErr := os.Mkdir(dir, 0o755)
+	_, readErr := os.ReadFile(dir)
+	var syntaxErr error = &json.SyntaxError{}
+	tests := []struct {
+		err      error
+		code     codes.Code
+		httpCode uint32
+	}{
+		{context.Canceled, codes.Canceled, 499},
+		{fmt.Errorf("query: %w", context.DeadlineExceeded), codes.DeadlineExceeded, 504},
+		{&url.Error{Op: "Get", URL: "http://x", Err: timeoutError{}}, codes.DeadlineExceeded, 504},
+		{&url.Error{Op: "Get", URL: "http://x", Err: stderrors.New("connection refused")}, codes.Unavailable, 503},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: stderrors.New("connection refused")}, codes.Unavailable, 503},
+		{&net.DNSError{Err: "timeout", Name: "x", IsTimeout: true}, codes.DeadlineExceeded, 504},
+		{&fs.PathError{Op: "open", Path: "a", Err: fs.ErrNotExist}, codes.NotFound, 404},
+		{fs.ErrPermission, codes.PermissionDenied, 403},
+		{os.ErrExist, codes.AlreadyExists, 409},
+		{openErr, codes.NotFound, 404},
+		{mkdirErr, codes.AlreadyExists, 409},
+		{syntaxErr, codes.InvalidArgument, 400},
+		{numErr, codes.InvalidArgument, 400},
+		{status.Error(codes.ResourceExhausted, "quota"), codes.ResourceExhausted, 429},
+	}
+	for _, tt := range tests {
+		err := FromError(tt.err)
+		codeErr, ok := AsCodeError(err)
+		if !ok || codeErr.Code != tt.code || codeErr.HTTPCode != tt.httpCode {
+			t.Errorf("FromError(%v) = %#v", tt.err, err)
+			continue
+		}
+		if !stderrors.Is(err, tt.err) || Cause(err) != tt.err {
+			t.Errorf("FromError(%v) lost the original error", tt.err)
+		}
+		if err.Error() != tt.err.Error() {
+			t.Errorf("FromError(%v) message = %q", tt.err, err.Error())
+		}
+	}
+
+	if IsRetryable(FromError(context.Canceled)) || !IsRetryable(FromError(&url.Error{Err: stderrors.New("refused")})) {
+		t.Error("unexpected retryability")
+	}
+	plain := stderrors.New("plain")
+	if FromError(plain) != plain || FromError(readErr) != readErr {
+		t.Error("unknown errors must be returned unchanged")
+	}
+	codeErr := NotFoundf("order")
+	if FromError(codeErr) != codeErr {
+		t.Error("code errors must be returned unchanged")
+	}
+}
+
+func TestRegisterClassifier(t *testing.T) {
+	defer func(list []Classifier) { classifiers.list = list }(classifiers.list)
+	RegisterClassifier(func(err error) error {
+		if stderrors.Is(err, fs.ErrNotExist) {
+			return NewNotValid(err, "missing upload")
+		}
+		return nil
+	})
+	if err := FromError(fs.ErrNotExist); !IsNotValid(err) {
+		t.Fatalf("the registered classifier is not consulted first: %#v", err)
+	}
+	if codeErr, _ := AsCodeError(FromError(context.Canceled)); codeErr == nil || codeErr.Code != codes.Canceled {
+		t.Fatalf("the built-in classifiers are not consulted")
+	}
+}